@@ -0,0 +1,40 @@
+package gohl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AppDir 应用数据目录的位置
+type AppDir int
+
+const (
+	AppDirRoaming  AppDir = iota // 随用户漫游的配置目录：os.UserConfigDir（Windows 为 %APPDATA%）
+	AppDirLocal                  // 本机目录：os.UserCacheDir（Windows 为 %LOCALAPPDATA%）
+	AppDirPortable               // 便携模式：可执行文件所在目录
+)
+
+// ResolveAppDir 返回应用数据目录 <位置>/<appName>，不会创建目录
+func ResolveAppDir(dir AppDir, appName string) (string, error) {
+	var base string
+	var err error
+	switch dir {
+	case AppDirRoaming:
+		base, err = os.UserConfigDir()
+	case AppDirLocal:
+		base, err = os.UserCacheDir()
+	case AppDirPortable:
+		var exe string
+		if exe, err = os.Executable(); err == nil {
+			base = filepath.Dir(exe)
+		}
+	default:
+		return "", fmt.Errorf("unknown app dir: %d", dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("无法获取应用数据目录: %w", err)
+	}
+
+	return filepath.Join(base, appName), nil
+}
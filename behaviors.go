@@ -14,6 +14,13 @@ func init() {
 	builtinBehaviors["hyperlink"] = HyperlinkBehavior()
 }
 
+// GOHL_EVENT_CODE_BASE gohl 内置 behavior 发出的事件码从该值开始，到 SINKING（0x8000）之前为止
+// 应用自定义的事件码应在 FIRST_APPLICATION_EVENT_CODE 与该值之间，避免与内置事件冲突
+const GOHL_EVENT_CODE_BASE = 0x7F00
+
+// TABS_SELECTION_CHANGED tabs behavior 切换标签后发出的事件，Source 为新选中的标签
+const TABS_SELECTION_CHANGED = GOHL_EVENT_CODE_BASE + 1
+
 func TabsBehavior() *EventHandler {
 	return &EventHandler{
 		OnAttached: func(he HELEMENT) {
@@ -202,6 +209,7 @@ func selectTab(tabsEl *Element, tabEl *Element) bool {
 	tabEl.SetAttr("selected", "")
 	panel.SetState(STATE_COLLAPSED, false)
 	panel.SetState(STATE_EXPANDED, true)
+	tabsEl.PostEvent(TABS_SELECTION_CHANGED, tabEl, 0)
 
 	return true
 }
@@ -0,0 +1,228 @@
+// gohl-storage 查看和修复 gohl Storage 的数据文件（storage.dat）
+//
+// 用法:
+//
+//	gohl-storage <file> dump [-format json|jsonl]
+//	gohl-storage <file> get <key>
+//	gohl-storage <file> set [-type string|int|float|bool] [-ttl 1h] <key> <value>
+//	gohl-storage <file> del <key>
+//	gohl-storage <file> ttl <key> [duration|persist]
+//	gohl-storage <file> import [-mode merge|skip|replace] <export.json>
+//	gohl-storage <file> compact
+//
+// 使用 gob.Register 注册的自定义类型无法在本工具中解码，这些值以 gohl.UndecodedValue 读取：
+// dump 和 get 以 "gob" 类型输出原始数据，修改其他键或 compact 时原样写回；SetTyped 写入的值不受影响
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/forbe/gohl"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, `用法: gohl-storage <file> <command> [arguments]
+
+命令:
+  dump [-format json|jsonl]                       导出全部数据
+  get <key>                                       查看键的类型、值和过期时间
+  set [-type string|int|float|bool] [-ttl 1h] <key> <value>
+                                                  写入键
+  del <key>                                       删除键
+  ttl <key> [duration|persist]                    查看或修改过期时间
+  import [-mode merge|skip|replace] <export.json> 导入 dump 生成的数据
+  compact                                         清理过期数据并重写文件`)
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 3 {
+		usage()
+	}
+
+	path, cmd, args := os.Args[1], os.Args[2], os.Args[3:]
+	if _, err := os.Stat(path); err != nil {
+		fatal(err)
+	}
+
+	s, err := gohl.NewStorageWithPath(path)
+	if err != nil {
+		fatal(err)
+	}
+
+	switch cmd {
+	case "dump":
+		err = dump(s, args)
+	case "get":
+		err = get(s, args)
+	case "set":
+		err = set(s, args)
+	case "del":
+		err = del(s, args)
+	case "ttl":
+		err = ttl(s, args)
+	case "import":
+		err = importFile(s, args)
+	case "compact":
+		err = s.Compact()
+	default:
+		usage()
+	}
+
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "gohl-storage:", err)
+	os.Exit(1)
+}
+
+func dump(s *gohl.Storage, args []string) error {
+	fs := flag.NewFlagSet("dump", flag.ExitOnError)
+	format := fs.String("format", "json", "输出格式: json 或 jsonl")
+	fs.Parse(args)
+
+	switch *format {
+	case "json":
+		return s.Export(os.Stdout, gohl.ExportJSON)
+	case "jsonl":
+		return s.Export(os.Stdout, gohl.ExportJSONLines)
+	default:
+		return fmt.Errorf("unknown format: %s", *format)
+	}
+}
+
+func get(s *gohl.Storage, args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+	key := args[0]
+	if !s.Exists(key) {
+		return fmt.Errorf("key not found: %s", key)
+	}
+
+	// 复用导出格式输出，自定义类型也能显示类型名和原始数据
+	var buf bytes.Buffer
+	if err := s.ExportKey(&buf, key); err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	fmt.Print(out.String())
+	return nil
+}
+
+func set(s *gohl.Storage, args []string) error {
+	fs := flag.NewFlagSet("set", flag.ExitOnError)
+	typ := fs.String("type", "string", "值类型: string, int, float 或 bool")
+	ttl := fs.Duration("ttl", 0, "过期时间，0 表示不过期")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		usage()
+	}
+	key, raw := fs.Arg(0), fs.Arg(1)
+
+	var value interface{}
+	var err error
+	switch *typ {
+	case "string":
+		value = raw
+	case "int":
+		value, err = strconv.ParseInt(raw, 10, 64)
+	case "float":
+		value, err = strconv.ParseFloat(raw, 64)
+	case "bool":
+		value, err = strconv.ParseBool(raw)
+	default:
+		return fmt.Errorf("unknown type: %s", *typ)
+	}
+	if err != nil {
+		return fmt.Errorf("无效的值 %q: %w", raw, err)
+	}
+
+	if *ttl > 0 {
+		return s.SetWithTTL(key, value, *ttl)
+	}
+	return s.Set(key, value)
+}
+
+func del(s *gohl.Storage, args []string) error {
+	if len(args) != 1 {
+		usage()
+	}
+	if !s.Exists(args[0]) {
+		return fmt.Errorf("key not found: %s", args[0])
+	}
+	return s.Delete(args[0])
+}
+
+func ttl(s *gohl.Storage, args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		usage()
+	}
+	key := args[0]
+	if !s.Exists(key) {
+		return fmt.Errorf("key not found: %s", key)
+	}
+
+	if len(args) == 1 {
+		if remaining, ok := s.TTL(key); ok {
+			fmt.Println(remaining.Round(time.Second))
+		} else {
+			fmt.Println("persist")
+		}
+		return nil
+	}
+
+	if args[1] == "persist" {
+		return s.Persist(key)
+	}
+	d, err := time.ParseDuration(args[1])
+	if err != nil {
+		return fmt.Errorf("无效的过期时间 %q: %w", args[1], err)
+	}
+	return s.Expire(key, d)
+}
+
+func importFile(s *gohl.Storage, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	mode := fs.String("mode", "merge", "导入模式: merge, skip 或 replace")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		usage()
+	}
+
+	modes := map[string]gohl.ImportMode{
+		"merge":   gohl.ImportMerge,
+		"skip":    gohl.ImportSkipExisting,
+		"replace": gohl.ImportReplace,
+	}
+	m, ok := modes[*mode]
+	if !ok {
+		return fmt.Errorf("unknown mode: %s", *mode)
+	}
+
+	f, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	n, err := s.Import(f, m)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已导入 %d 个键\n", n)
+	return nil
+}
@@ -196,6 +196,7 @@ func NewElementFromHandle(h HELEMENT) *Element {
 }
 
 func NewElement(tagName string) *Element {
+	mustInitialized()
 	var handle uintptr = 0
 	tagBytes := append([]byte(tagName), 0)
 	if ret := HTMLayoutCreateElement(&tagBytes[0], nil, &handle); ret != HLDOM_OK {
@@ -205,6 +206,9 @@ func NewElement(tagName string) *Element {
 }
 
 func RootElement(hwnd uint32) *Element {
+	if ensureInitialized() != nil {
+		return nil
+	}
 	var handle uintptr = 0
 	if ret := HTMLayoutGetRootElement(uintptr(hwnd), &handle); ret != HLDOM_OK {
 		return nil
@@ -213,6 +217,9 @@ func RootElement(hwnd uint32) *Element {
 }
 
 func FindElement(hwnd uint32, x, y int) *Element {
+	if ensureInitialized() != nil {
+		return nil
+	}
 	var handle uintptr = 0
 	pt := struct{ X, Y int32 }{int32(x), int32(y)}
 	if ret := HTMLayoutFindElement(uintptr(hwnd), pt, &handle); ret != HLDOM_OK {
@@ -225,6 +232,7 @@ func FindElement(hwnd uint32, x, y int) *Element {
 }
 
 func FocusedElement(hwnd uint32) *Element {
+	mustInitialized()
 	var handle uintptr = 0
 	if ret := HTMLayoutGetFocusElement(uintptr(hwnd), &handle); ret != HLDOM_OK {
 		domPanic(ret, "Failed to get focus element")
@@ -681,6 +689,9 @@ func (e *Element) GetElementUid() uint32 {
 }
 
 func ElementByUid(hwnd uint32, uid uint32) *Element {
+	if ensureInitialized() != nil {
+		return nil
+	}
 	var he uintptr
 	if ret := HTMLayoutGetElementByUID(uintptr(hwnd), uid, &he); ret != HLDOM_OK {
 		return nil
@@ -692,6 +703,7 @@ func ElementByUid(hwnd uint32, uid uint32) *Element {
 }
 
 func CreateElement(tag string, text string) *Element {
+	mustInitialized()
 	tagBytes := append([]byte(tag), 0)
 	textUtf16, _ := syscall.UTF16PtrFromString(text)
 	var he uintptr
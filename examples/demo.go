@@ -4,13 +4,11 @@ import (
 	"archive/zip"
 	"bytes"
 	"embed"
-	"io"
 	"log"
 	"math/rand"
 	"os"
 	"os/exec"
 	"strconv"
-	"strings"
 	"sync"
 	"time"
 
@@ -30,8 +28,14 @@ var W *gohl.Window
 const WM_TRAYMSG = 0x0400 + 1
 
 func main() {
+	if err := gohl.Init(gohl.Options{Logger: log.Default()}); err != nil {
+		log.Fatal(err)
+	}
+
 	initEmbedZip()
-	gohl.RegisterResourceLoader("embed", embedLoader)
+	if embedReader != nil {
+		gohl.RegisterFS("embed", embedReader)
+	}
 
 	W = gohl.NewWindow(gohl.WindowConfig{
 		Title:        "联机助手",
@@ -119,11 +123,14 @@ func main() {
 		return true
 	}
 
+	var err error
 	if FileExists("app.html") {
-		W.LoadFile("app.html").Run()
+		err = W.LoadFile("app.html").Run()
 	} else {
-		html := loadEmbedRes("embed://app.html")
-		W.SetHtml(html).Run()
+		err = W.LoadFile("embed://app.html").Run()
+	}
+	if err != nil {
+		log.Fatal(err)
 	}
 }
 
@@ -245,40 +252,6 @@ func initEmbedZip() {
 	})
 }
 
-func loadEmbedRes(uri string) string {
-	uri = strings.TrimPrefix(uri, "embed://")
-	uri = strings.TrimSuffix(uri, "/")
-
-	if embedReader == nil {
-		return ""
-	}
-
-	for _, file := range embedReader.File {
-		if file.Name == uri {
-			rc, err := file.Open()
-			if err != nil {
-				return ""
-			}
-			defer rc.Close()
-			data, err := io.ReadAll(rc)
-			if err != nil {
-				return ""
-			}
-			return string(data)
-		}
-	}
-	return ""
-}
-
-func embedLoader(uri string) ([]byte, uint32, bool) {
-	data := loadEmbedRes(uri)
-	if data == "" {
-		return nil, 0, false
-	}
-	filename := strings.TrimPrefix(uri, "embed://")
-	return []byte(data), gohl.GetResourceDataType(filename), true
-}
-
 func alert(msg string) {
 	showModal("default-modal", "提示", msg, func(submmit bool) bool {
 		return true
@@ -303,7 +276,7 @@ func showNewWindow(uri string, width, height int) {
 		Icon:         gohl.LoadIconFromResource(2),
 		Center:       true,
 	})
-	w.SetHtml(loadEmbedRes(uri)).Run()
+	w.LoadFile(uri).Run()
 }
 
 func FileExists(path string) bool {
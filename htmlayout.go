@@ -487,6 +487,9 @@ func ProcNoDefault(hwnd, msg uint32, wparam, lparam uintptr) (uintptr, bool) {
 
 // Load html contents into window
 func LoadHtml(hwnd uint32, data []byte, baseUrl string) error {
+	if err := ensureInitialized(); err != nil {
+		return err
+	}
 	if len(data) > 0 {
 		baseUrlPtr := stringToUtf16Ptr(baseUrl)
 		if !HTMLayoutLoadHtmlEx(uintptr(hwnd), &data[0], uint32(len(data)), baseUrlPtr) {
@@ -498,6 +501,9 @@ func LoadHtml(hwnd uint32, data []byte, baseUrl string) error {
 
 // Load resource (file or url) into window
 func LoadResource(hwnd uint32, uri string) error {
+	if err := ensureInitialized(); err != nil {
+		return err
+	}
 	uriPtr := stringToUtf16Ptr(uri)
 	if !HTMLayoutLoadFile(uintptr(hwnd), uriPtr) {
 		return errors.New("HTMLayoutLoadFile failed")
@@ -506,6 +512,9 @@ func LoadResource(hwnd uint32, uri string) error {
 }
 
 func SetOption(hwnd uint32, option uint, value uint) bool {
+	if ensureInitialized() != nil {
+		return false
+	}
 	return HTMLayoutSetOption(uintptr(hwnd), uint32(option), uint32(value))
 }
 
@@ -519,10 +528,14 @@ const (
 )
 
 func DataReady(hwnd uint32, uri *uint16, data []byte) bool {
+	if len(data) == 0 || ensureInitialized() != nil {
+		return false
+	}
 	return HTMLayoutDataReady(uintptr(hwnd), uri, &data[0], uint32(len(data)))
 }
 
 func AttachWindowEventHandler(hwnd uint32, handler *EventHandler) {
+	mustInitialized()
 	key := uintptr(hwnd)
 
 	if oldHandle, exists := windowEventHandles[hwnd]; exists {
@@ -569,6 +582,7 @@ func DetachWindowEventHandler(hwnd uint32) {
 }
 
 func AttachNotifyHandler(hwnd uint32, handler *NotifyHandler) {
+	mustInitialized()
 	key := uintptr(hwnd)
 	notifyHandlers[key] = handler
 	HTMLayoutSetCallback(key, uintptr(goNotifyProc), key)
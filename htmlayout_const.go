@@ -380,6 +380,7 @@ const (
 	HWND_DISCARD_CREATION = 1 // Do not create any controls
 	LOAD_OK               = 0 // Use default loader or outData/outDataSize if they are set
 	LOAD_DISCARD          = 1 // Do not load resource at all
+	LOAD_DELAYED          = 2 // Data will be delivered later by HTMLayoutDataReady
 )
 
 // Content insertion locations
@@ -3,7 +3,7 @@ package gohl
 import (
 	"fmt"
 	"os"
-	"path/filepath"
+	"strings"
 	"syscall"
 	"unsafe"
 )
@@ -36,36 +36,31 @@ type VALUE struct {
 	D uint64
 }
 
-func init() {
-	// 初始化资源目录
-	extractResources()
-	// 初始化 htmlayout 库
-	initHtmlayoutLib()
-}
-
-func initHtmlayoutLib() {
-	if resourcesDir == "" {
-		fmt.Println("资源目录未初始化")
-		return
+// loadHtmlayoutLib 加载 htmlayout.dll 并查找所有导出函数
+// verify 为 true 时，拒绝加载与资源清单不一致（被篡改或损坏）的 DLL
+func loadHtmlayoutLib(dllPath string, verify bool) error {
+	if _, err := os.Stat(dllPath); err != nil {
+		return fmt.Errorf("htmlayout.dll 不存在: %w", err)
 	}
 
-	dllPath := filepath.Join(resourcesDir, "htmlayout.dll")
-
-	if _, err := os.Stat(dllPath); os.IsNotExist(err) {
-		fmt.Printf("htmlayout.dll 不存在: %s\n", dllPath)
-		return
+	if verify {
+		if err := verifyResourceFile(dllPath, "htmlayout.dll"); err != nil {
+			return fmt.Errorf("拒绝加载 htmlayout.dll: %w", err)
+		}
 	}
 
-	var err error
-	htmlayoutLib, err = syscall.LoadDLL(dllPath)
+	lib, err := syscall.LoadDLL(dllPath)
 	if err != nil {
-		fmt.Printf("加载 htmlayout.dll 失败: %v\n", err)
-		return
+		return fmt.Errorf("加载 htmlayout.dll 失败: %w", err)
 	}
+	htmlayoutLib = lib
 
-	fmt.Printf("成功加载 htmlayout.dll: %s\n", dllPath)
+	if err := initHtmlayoutFunctions(); err != nil {
+		return err
+	}
 
-	initHtmlayoutFunctions()
+	logf("成功加载 htmlayout.dll: %s", dllPath)
+	return nil
 }
 
 var (
@@ -140,9 +135,15 @@ var (
 	procValueIntDataSet                   *syscall.Proc
 )
 
-func initHtmlayoutFunctions() {
-	if htmlayoutLib == nil {
-		return
+func initHtmlayoutFunctions() error {
+	var missing []string
+	mustFindProc := func(name string) *syscall.Proc {
+		proc, err := htmlayoutLib.FindProc(name)
+		if err != nil {
+			missing = append(missing, name)
+			return nil
+		}
+		return proc
 	}
 
 	procHTMLayoutProcND = mustFindProc("HTMLayoutProcND")
@@ -214,16 +215,11 @@ func initHtmlayoutFunctions() {
 	procValueStringDataSet = mustFindProc("ValueStringDataSet")
 	procValueIntData = mustFindProc("ValueIntData")
 	procValueIntDataSet = mustFindProc("ValueIntDataSet")
-}
 
-func mustFindProc(name string) *syscall.Proc {
-	proc, err := htmlayoutLib.FindProc(name)
-	if err != nil {
-		fmt.Printf("找不到函数 %s: %v\n", name, err)
-		return nil
+	if len(missing) > 0 {
+		return fmt.Errorf("htmlayout.dll 缺少函数: %s", strings.Join(missing, ", "))
 	}
-	// fmt.Printf("加载函数 %s: %v\n", name, proc.Addr())
-	return proc
+	return nil
 }
 
 func HTMLayoutProcND(hwnd uintptr, msg uint32, wparam uintptr, lparam uintptr, handled *int32) int {
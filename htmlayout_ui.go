@@ -1,6 +1,7 @@
 package gohl
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
@@ -12,15 +13,6 @@ import (
 	"unsafe"
 )
 
-func init() {
-	//take care!
-	//主goroutine必须锁定在主线程，否则被调度之后（在go的调度度下，主goroutine也不一定总是运行在主线程），会导致HTMLayout崩溃（GUI操作需要在主线程）
-	runtime.LockOSThread()
-
-	// DPI 感知由 manifest 文件设置 (PerMonitorV2)
-	// 程序需要自己根据 DPI 缩放窗口大小
-}
-
 // GetDpiScale 获取当前 DPI 缩放因子（相对于 96 DPI）
 func GetDpiScale() float64 {
 	user32 := syscall.NewLazyDLL("user32.dll")
@@ -201,6 +193,9 @@ type WindowConfig struct {
 	Rounded      bool    // 圆角窗口
 	CornerRadius int     // 圆角半径，默认10
 	Handler      NotifyHandler
+
+	// ResourceCacheSize 资源缓存的字节上限，0 使用 DefaultResourceCacheSize，负数表示不缓存
+	ResourceCacheSize int64
 }
 
 type U struct {
@@ -253,6 +248,12 @@ type Window struct {
 	closing       bool
 	dispatcher    *Dispatcher
 	eventHandlers map[uint32]ElementHandler
+	persist       persistQueue
+	resources     *resourceCache
+	policy        *ResourcePolicy
+	ctx           context.Context
+	cancel        context.CancelFunc
+	pendingLoads  map[string]bool
 
 	OnButtonClick        ElementHandler
 	OnMouse              MouseHandler
@@ -275,8 +276,11 @@ func NewWindow(config WindowConfig) *Window {
 		config.Height = 300
 	}
 	gw := &Window{
-		config: config,
+		config:       config,
+		resources:    newResourceCache(config.ResourceCacheSize),
+		pendingLoads: make(map[string]bool),
 	}
+	gw.ctx, gw.cancel = context.WithCancel(context.Background())
 	if config.Handler.Behaviors == nil {
 		config.Handler.Behaviors = map[string]*EventHandler{}
 	}
@@ -289,8 +293,14 @@ func (w *Window) SetHtml(html string) *Window {
 	return w
 }
 
+// LoadFile 加载本地文件或 scheme://path 形式的资源（如 RegisterFS 注册的资源），
+// 文档中的相对 URL 相对于该地址解析
 func (w *Window) LoadFile(uri string) *Window {
 	w.htmlContent = ""
+	if strings.Contains(uri, "://") {
+		w.loadFile = uri
+		return w
+	}
 	absPath, err := filepath.Abs(uri)
 	if err != nil {
 		log.Println("LoadFile error:", err)
@@ -307,21 +317,119 @@ func (w *Window) SetEventHandler(handler *EventHandler) *Window {
 	return w
 }
 
+// SetNotifyHandler 设置窗口的通知处理器
+// 窗口使用 handler 的副本并在副本上添加缓存、资源策略和状态恢复，handler 本身不会被修改，可以用于多个窗口
 func (w *Window) SetNotifyHandler(handler *NotifyHandler) *Window {
-	if handler.OnLoadData == nil {
-		handler.OnLoadData = defaultOnLoadData
+	h := *handler
+
+	// 资源策略作用于所有 HLN_LOAD_DATA，包括自定义的 OnLoadData
+	onLoadData := h.OnLoadData
+	if onLoadData == nil {
+		onLoadData = w.onLoadData
+	}
+	h.OnLoadData = func(params *NmhlLoadData) uintptr {
+		if params.Uri == nil {
+			return onLoadData(params)
+		}
+		uri := utf16ToString(params.Uri)
+		if !w.allowResource(uri) {
+			return LOAD_DISCARD
+		}
+		ret := onLoadData(params)
+		if ret != LOAD_DISCARD && params.OutData != 0 && !w.allowResourceSize(uri, int(params.OutDataSize)) {
+			params.OutData = 0
+			params.OutDataSize = 0
+			return LOAD_DISCARD
+		}
+		return ret
+	}
+	// HTMLayout 加载完成后不再引用交给它的数据
+	onDataLoaded := h.OnDataLoaded
+	h.OnDataLoaded = func(params *NmhlDataLoaded) uintptr {
+		if params.Uri != nil {
+			w.resources.unpin(utf16ToString(params.Uri))
+		}
+		if onDataLoaded != nil {
+			return onDataLoaded(params)
+		}
+		return 0
+	}
+	// 文档加载完成后恢复 -gohl-persist 元素的值
+	onDocumentComplete := h.OnDocumentComplete
+	h.OnDocumentComplete = func() uintptr {
+		w.restorePersisted()
+		if onDocumentComplete != nil {
+			return onDocumentComplete()
+		}
+		return 0
 	}
-	w.notifyHandler = handler
+	w.notifyHandler = &h
 	return w
 }
 
 type ResourceLoader func(uri string) ([]byte, uint32, bool)
 
-var resourceLoaders = make(map[string]ResourceLoader)
-var loadedResources = make(map[string][]byte)
+var (
+	resourceLoadersMu sync.RWMutex
+	resourceLoaders   = make(map[string]ResourceLoader)
+	// uncachedSchemes 每次请求都要重新加载、不进入窗口缓存的 scheme（如 RegisterHandler 注册的动态内容）
+	uncachedSchemes = make(map[string]bool)
+)
 
+// RegisterResourceLoader 注册 scheme:// 资源的加载器，scheme 不区分大小写；loader 为 nil 时取消注册
 func RegisterResourceLoader(scheme string, loader ResourceLoader) {
+	registerResourceLoader(scheme, loader, true)
+}
+
+// registerResourceLoader 注册加载器，cacheable 为 false 时该 scheme 的资源不进入窗口缓存
+func registerResourceLoader(scheme string, loader ResourceLoader, cacheable bool) {
+	resourceLoadersMu.Lock()
+	defer resourceLoadersMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	invalidateResourceCaches()
+	delete(asyncLoaders, scheme)
+	delete(uncachedSchemes, scheme)
+	if loader == nil {
+		delete(resourceLoaders, scheme)
+		return
+	}
 	resourceLoaders[scheme] = loader
+	if !cacheable {
+		uncachedSchemes[scheme] = true
+	}
+}
+
+// windowCacheable 检查 URI 的资源能否从窗口缓存中读取
+// 中间件链中有带 etag 的 ResourceCaching 时每次加载都经过中间件链，由它负责缓存和校验
+func windowCacheable(uri string) bool {
+	if resourceChainValidating() {
+		return false
+	}
+
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok {
+		return true
+	}
+
+	resourceLoadersMu.RLock()
+	defer resourceLoadersMu.RUnlock()
+
+	return !uncachedSchemes[strings.ToLower(scheme)]
+}
+
+// resourceLoaderFor 按 URI 的 scheme 查找加载器
+func resourceLoaderFor(uri string) (ResourceLoader, bool) {
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok {
+		return nil, false
+	}
+
+	resourceLoadersMu.RLock()
+	defer resourceLoadersMu.RUnlock()
+
+	loader, ok := resourceLoaders[strings.ToLower(scheme)]
+	return loader, ok
 }
 
 func (w *Window) Dispatch(fn func()) {
@@ -330,40 +438,73 @@ func (w *Window) Dispatch(fn func()) {
 	}
 }
 
-func defaultOnLoadData(params *NmhlLoadData) uintptr {
+// onLoadData 默认的 HLN_LOAD_DATA 处理：依次查找窗口缓存、自定义加载器和内置资源
+func (w *Window) onLoadData(params *NmhlLoadData) uintptr {
 	if params.Uri == nil {
 		return 0
 	}
 
 	uri := utf16ToString(params.Uri)
+	cacheable := windowCacheable(uri)
 
-	// 先检查缓存
-	if data, ok := loadedResources[uri]; ok && len(data) > 0 {
-		params.OutData = uintptr(unsafe.Pointer(&data[0]))
-		params.OutDataSize = int32(len(data))
-		params.DataType = GetResourceDataType(uri)
-		return 1
+	var data []byte
+	var dataType uint32
+	ok := false
+	if cacheable {
+		data, dataType, ok = w.resources.get(uri)
 	}
+	if !ok {
+		if async, isAsync := asyncLoaderFor(uri); isAsync {
+			return w.loadAsync(params, uri, async)
+		}
 
-	// 检查自定义资源加载器
-	for scheme, loader := range resourceLoaders {
-		prefix := scheme + "://"
-		if strings.HasPrefix(uri, prefix) {
-			data, dataType, ok := loader(uri)
-			if !ok || len(data) == 0 {
-				return 0
-			}
-			loadedResources[uri] = data
-			params.OutData = uintptr(unsafe.Pointer(&data[0]))
-			params.OutDataSize = int32(len(data))
-			params.DataType = dataType
-			return 1
+		data, dataType, ok = loadResource(uri)
+		if !ok || len(data) == 0 {
+			return 0
+		}
+		dataType = DetectResourceDataType(uri, data, dataType)
+		if cacheable {
+			w.resources.put(uri, data, dataType)
 		}
 	}
+	if !w.allowResourceSize(uri, len(data)) {
+		return LOAD_DISCARD
+	}
+
+	w.outputResource(params, uri, data, dataType)
+	return 1
+}
+
+// outputResource 将数据交给 HTMLayout，数据在 HLN_DATA_LOADED 之前必须保持有效
+func (w *Window) outputResource(params *NmhlLoadData, uri string, data []byte, dataType uint32) {
+	if dataType == HLRT_DATA_RAW {
+		// 字体等没有对应类型的数据按请求方期望的类型交付
+		dataType = params.DataType
+	} else if dataType != params.DataType {
+		log.Printf("[Resource] %s 的数据类型为 %d，但请求的类型为 %d", uri, dataType, params.DataType)
+	}
+
+	w.resources.pin(uri, data)
+	params.OutData = uintptr(unsafe.Pointer(&data[0]))
+	params.OutDataSize = int32(len(data))
+	params.DataType = dataType
+}
+
+// loadResource 经过资源中间件加载 URI
+func loadResource(uri string) ([]byte, uint32, bool) {
+	return resourceChain()(uri)
+}
+
+// loadResourceDirect 通过自定义加载器或内置资源目录加载 URI
+func loadResourceDirect(uri string) ([]byte, uint32, bool) {
+	// 检查自定义资源加载器
+	if loader, ok := resourceLoaderFor(uri); ok {
+		return loader(uri)
+	}
 
 	// 检查是否是 resources:// 开头
 	if !isResourcesURI(uri) {
-		return 0
+		return nil, 0, false
 	}
 
 	// 提取资源名称，移除 resources:// 前缀和末尾的斜杠
@@ -373,24 +514,11 @@ func defaultOnLoadData(params *NmhlLoadData) uintptr {
 
 	data, err := readFileBytes(filePath)
 	if err != nil {
-		return 0
-	}
-
-	// 保存到 map，确保数据在函数返回后仍然有效
-	loadedResources[uri] = data
-
-	// 使用 map 中存储的数据指针，而不是局部变量 data 的指针
-	storedData := loadedResources[uri]
-	if len(storedData) == 0 {
-		return 0
+		return nil, 0, false
 	}
 
-	params.OutData = uintptr(unsafe.Pointer(&storedData[0]))
-	params.OutDataSize = int32(len(storedData))
 	// 根据文件扩展名设置数据类型
-	params.DataType = GetResourceDataType(resourceName)
-
-	return 1
+	return data, resourceDataTypeByExt(resourceName), true
 }
 
 // isResourcesURI 检查是否是 resources:// URI
@@ -398,25 +526,6 @@ func isResourcesURI(uri string) bool {
 	return strings.HasPrefix(uri, "resources://")
 }
 
-// GetResourceDataType 根据文件扩展名返回资源数据类型
-func GetResourceDataType(filename string) uint32 {
-	ext := filepath.Ext(filename)
-	switch ext {
-	case ".html", ".htm":
-		return HLRT_DATA_HTML
-	case ".css":
-		return HLRT_DATA_STYLE
-	case ".js":
-		return HLRT_DATA_SCRIPT
-	case ".png", ".jpg", ".jpeg", ".gif", ".bmp", ".ico", ".svg":
-		return HLRT_DATA_IMAGE
-	case ".ttf", ".otf", ".woff", ".woff2":
-		return HLRT_DATA_HTML
-	default:
-		return HLRT_DATA_HTML
-	}
-}
-
 func readFileBytes(path string) ([]byte, error) {
 	data, err := os.ReadFile(path)
 	return data, err
@@ -447,7 +556,18 @@ func (w *Window) GetElementById(id string) *Element {
 	return root.GetElementById(id)
 }
 
-func (w *Window) Run() {
+// Run 创建窗口并运行消息循环，直到窗口关闭
+// 未调用 Init 时会以默认配置初始化，初始化失败时返回 ErrNotInitialized
+func (w *Window) Run() error {
+	if err := ensureInitialized(); err != nil {
+		return err
+	}
+
+	//take care!
+	//窗口的创建和消息循环必须在同一个系统线程中，否则被调度之后会导致HTMLayout崩溃（GUI操作需要在创建窗口的线程）
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
 	className := syscall.StringToUTF16Ptr(w.config.ClassName)
 	hInstance, _, _ := procGetModuleHandle.Call()
 	cursor, _, _ := procLoadCursor.Call(IDC_ARROW)
@@ -473,7 +593,7 @@ func (w *Window) Run() {
 	}
 
 	if _, errno := registerClassEx(&wc); errno != ERROR_SUCCESS {
-		log.Panic("Failed to register window class: ", errno)
+		return fmt.Errorf("failed to register window class: %w", errno)
 	}
 
 	// 窗口样式设置
@@ -526,7 +646,7 @@ func (w *Window) Run() {
 		width, height,
 		0, 0, hInstance, 0)
 	if errno != ERROR_SUCCESS {
-		log.Panic("Failed to create window: ", errno)
+		return fmt.Errorf("failed to create window: %w", errno)
 	}
 
 	w.hwnd = hwnd
@@ -547,13 +667,14 @@ func (w *Window) Run() {
 	var msg Msg
 	for {
 		if r, errno := getMessage(&msg, 0, 0, 0); errno != ERROR_SUCCESS {
-			log.Panic("GetMessage error: ", errno)
+			return fmt.Errorf("GetMessage error: %w", errno)
 		} else if r == 0 {
 			break
 		}
 		translateMessage(&msg)
 		dispatchMessage(&msg)
 	}
+	return nil
 }
 
 func (w *Window) wndProc(hwnd uintptr, msg uint32, wparam uintptr, lparam uintptr) uintptr {
@@ -634,10 +755,11 @@ func (w *Window) wndProc(hwnd uintptr, msg uint32, wparam uintptr, lparam uintpt
 		// 先停止 HTMLayout 动画线程
 		SetOption(uint32(hwnd), HTMLAYOUT_ANIMATION_THREAD, 0)
 
-		// 清理 loadedResources 中的资源引用
-		for k := range loadedResources {
-			delete(loadedResources, k)
-		}
+		// 取消进行中的异步加载，释放资源缓存和交给 HTMLayout 的数据引用
+		w.cancel()
+		w.resources.clear(true)
+		// 写入尚未保存的 -gohl-persist 值
+		w.flushPersisted()
 
 		if w.eventHandler != nil {
 			DetachWindowEventHandler(w.hwnd)
@@ -789,6 +911,8 @@ func (w *Window) setupDefaultEventHandler() {
 				return false
 			}
 
+			w.persistElement(elem, params)
+
 			switch params.Cmd & 0xFF {
 			case BUTTON_CLICK:
 				if _, hasMin := elem.Attr("-gohl-min"); hasMin {
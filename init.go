@@ -0,0 +1,127 @@
+package gohl
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"sync"
+)
+
+// ExtractMode 内置资源（htmlayout.dll、字体）的释放方式
+type ExtractMode int
+
+const (
+	ExtractAuto  ExtractMode = iota // 解压到以内容哈希命名的版本目录，已解压的版本直接复用
+	ExtractNever                    // 不解压，直接使用 ResourceDir 中已部署的文件
+)
+
+// Options Init 的配置
+type Options struct {
+	// ResourceDir ExtractAuto 时为各版本目录的上级目录，默认 <本机应用数据目录>/gohl；
+	// ExtractNever 时为 htmlayout.dll 等资源文件所在的目录
+	ResourceDir string
+	// DLLPath 指定 htmlayout.dll 的路径，设置后不再按资源清单校验哈希
+	DLLPath string
+	// Logger 初始化过程的日志，为 nil 时不输出
+	Logger *log.Logger
+	// Extract 资源释放方式，默认 ExtractAuto
+	Extract ExtractMode
+}
+
+// ErrNotInitialized 库未初始化或初始化失败
+// 入口函数在未调用 Init 时会以默认配置自动初始化，初始化失败时：
+// Window.Run、LoadHtml、LoadResource 返回该错误；SetOption、DataReady 返回 false；
+// RootElement、FindElement、ElementByUid 返回 nil；NewElement、CreateElement、FocusedElement、
+// AttachWindowEventHandler 和 AttachNotifyHandler 与其他 DOM 错误一样 panic，panic 的值包装了该错误
+// Element 的方法和 ShowDialog 等函数操作的元素只能从上述入口获得，不再单独检查；
+// Detach* 在未初始化时没有可分离的处理器，不做任何操作；
+// 低层的 HTMLayout* 和 Value* 包装函数不会自动初始化，未初始化时返回失败的结果
+var ErrNotInitialized = errors.New("gohl 未初始化")
+
+var (
+	initMu     sync.Mutex
+	initCalled bool
+	initErr    error
+	initLogger *log.Logger
+)
+
+// Init 释放资源并加载 htmlayout.dll，应在创建窗口之前调用
+// 未调用 Init 时，首次使用（如 Window.Run）会以默认配置自动初始化；
+// 初始化成功后重复调用不会重新初始化，初始化失败后可以换用其他配置重试
+func Init(opts Options) error {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	if initCalled && initErr == nil {
+		return nil
+	}
+	initCalled = true
+	initErr = initLocked(opts)
+	return initErr
+}
+
+// Initialized 库是否已成功初始化（htmlayout.dll 已加载）
+func Initialized() bool {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	return initCalled && initErr == nil
+}
+
+// ensureInitialized 确保库已初始化，未调用过 Init 时以默认配置初始化
+func ensureInitialized() error {
+	initMu.Lock()
+	defer initMu.Unlock()
+
+	if !initCalled {
+		initCalled = true
+		initErr = initLocked(Options{})
+	}
+	if initErr != nil {
+		return fmt.Errorf("%w: %v", ErrNotInitialized, initErr)
+	}
+	return nil
+}
+
+// mustInitialized 用于不返回 error 的入口，初始化失败时以包装了 ErrNotInitialized 的错误 panic
+func mustInitialized() {
+	if err := ensureInitialized(); err != nil {
+		panic(err)
+	}
+}
+
+func initLocked(opts Options) error {
+	initLogger = opts.Logger
+
+	switch opts.Extract {
+	case ExtractAuto:
+		baseDir := opts.ResourceDir
+		if baseDir == "" {
+			baseDir = resourcesBaseDir()
+		}
+		if err := extractResources(baseDir); err != nil {
+			return fmt.Errorf("释放资源失败: %w", err)
+		}
+	case ExtractNever:
+		if opts.ResourceDir == "" && opts.DLLPath == "" {
+			return errors.New("ExtractNever 需要设置 ResourceDir 或 DLLPath")
+		}
+		resourcesDir = opts.ResourceDir
+	default:
+		return fmt.Errorf("unknown extract mode: %d", opts.Extract)
+	}
+
+	dllPath := opts.DLLPath
+	if dllPath == "" {
+		dllPath = filepath.Join(resourcesDir, "htmlayout.dll")
+	}
+	return loadHtmlayoutLib(dllPath, opts.DLLPath == "")
+}
+
+// logf 输出初始化日志
+func logf(format string, args ...interface{}) {
+	if initLogger != nil {
+		initLogger.Printf(format, args...)
+	}
+}
@@ -0,0 +1,197 @@
+package gohl
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// persistAttr 需要自动保存状态的元素属性，值为 Storage 中的键
+const persistAttr = "-gohl-persist"
+
+// persistDelay 最后一次修改后延迟保存的时间，连续输入只写一次文件
+const persistDelay = 500 * time.Millisecond
+
+// persistQueue 窗口关联的 Storage 和等待写入的元素值，写入在定时器的 goroutine 中进行，字段都由 mu 保护
+type persistQueue struct {
+	mu      sync.Mutex
+	storage *Storage
+	pending map[string]interface{}
+	timer   *time.Timer
+}
+
+// SetStorage 为窗口关联 Storage，带 -gohl-persist="key" 属性的元素
+// （input、textarea、select、checkbox/radio 及 tabs behavior）在变化时把值保存到 key，
+// 并在文档加载完成时恢复；保存在最后一次修改 500ms 后于后台进行，窗口关闭时立即写入
+// 共用同一个 key 的一组 radio 保存选中项的 value
+func (w *Window) SetStorage(s *Storage) *Window {
+	// 之前的修改写入原来的 Storage
+	q := &w.persist
+	q.mu.Lock()
+	old, pending := q.takeLocked()
+	q.storage = s
+	q.mu.Unlock()
+
+	writePersisted(old, pending)
+	return w
+}
+
+// Storage 获取窗口关联的 Storage
+func (w *Window) Storage() *Storage {
+	q := &w.persist
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.storage
+}
+
+// persistElement 在元素状态变化时保存带 -gohl-persist 属性的元素的值
+func (w *Window) persistElement(elem *Element, params *BehaviorEventParams) {
+	if w.Storage() == nil || elem == nil {
+		return
+	}
+	key, ok := elem.Attr(persistAttr)
+	if !ok || key == "" {
+		return
+	}
+
+	var value interface{}
+	if params.Cmd&^HANDLED == TABS_SELECTION_CHANGED {
+		if params.Source == BAD_HELEMENT {
+			return
+		}
+		tab := NewElementFromHandle(params.Source)
+		if tab == nil {
+			return
+		}
+		value, _ = tab.Attr("panel")
+	} else {
+		switch params.Cmd & 0xFF {
+		case BUTTON_STATE_CHANGED:
+			if !isCheckable(elem) {
+				return
+			}
+			value = elem.IsChecked()
+			if radioValue, ok := elem.Attr("value"); ok && isRadio(elem) {
+				// radio 组只保存选中项的 value，取消选中的事件忽略
+				if !elem.IsChecked() {
+					return
+				}
+				value = radioValue
+			}
+		case EDIT_VALUE_CHANGED:
+			value = elem.Text()
+		case SELECT_SELECTION_CHANGED:
+			value, _ = elem.GetValue()
+		default:
+			return
+		}
+	}
+
+	w.queuePersist(key, value)
+}
+
+// queuePersist 记录待保存的值，并在 persistDelay 后于后台写入
+func (w *Window) queuePersist(key string, value interface{}) {
+	q := &w.persist
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.pending == nil {
+		q.pending = make(map[string]interface{})
+	}
+	q.pending[key] = value
+	if q.timer == nil {
+		q.timer = time.AfterFunc(persistDelay, w.flushPersisted)
+	} else {
+		q.timer.Reset(persistDelay)
+	}
+}
+
+// flushPersisted 立即写入所有待保存的值
+func (w *Window) flushPersisted() {
+	q := &w.persist
+	q.mu.Lock()
+	s, pending := q.takeLocked()
+	q.mu.Unlock()
+
+	writePersisted(s, pending)
+}
+
+// takeLocked 取出 Storage 和待保存的值并停止定时器（已加锁）
+func (q *persistQueue) takeLocked() (*Storage, map[string]interface{}) {
+	pending := q.pending
+	q.pending = nil
+	if q.timer != nil {
+		q.timer.Stop()
+		q.timer = nil
+	}
+	return q.storage, pending
+}
+
+// writePersisted 将待保存的值一次写入 Storage
+func writePersisted(s *Storage, pending map[string]interface{}) {
+	if len(pending) == 0 || s == nil {
+		return
+	}
+	if err := s.setMany(pending); err != nil {
+		log.Printf("[Persist] save failed: %v", err)
+	}
+}
+
+// restorePersisted 文档加载完成后，恢复带 -gohl-persist 属性的元素的值
+func (w *Window) restorePersisted() {
+	storage := w.Storage()
+	if storage == nil {
+		return
+	}
+	root := RootElement(w.hwnd)
+	if root == nil {
+		return
+	}
+
+	for _, elem := range root.Select("[" + persistAttr + "]") {
+		key, _ := elem.Attr(persistAttr)
+		if key == "" {
+			continue
+		}
+		value, exists := storage.Get(key)
+		if !exists {
+			continue
+		}
+
+		switch v := value.(type) {
+		case bool:
+			if isCheckable(elem) {
+				elem.SetState(STATE_CHECKED, v)
+			}
+		case string:
+			if isRadio(elem) {
+				radioValue, _ := elem.Attr("value")
+				elem.SetState(STATE_CHECKED, radioValue == v)
+				continue
+			}
+			if strip := elem.SelectFirst(".strip"); strip != nil {
+				if tab := strip.SelectFirst("[panel=\"" + v + "\"]"); tab != nil {
+					selectTab(elem, tab)
+				}
+				continue
+			}
+			elem.SetValue(v)
+		}
+	}
+}
+
+// isCheckable 判断元素是否为 checkbox 或 radio
+func isCheckable(elem *Element) bool {
+	typ, _ := elem.Attr("type")
+	typ = strings.ToLower(typ)
+	return typ == "checkbox" || typ == "radio"
+}
+
+// isRadio 判断元素是否为 radio
+func isRadio(elem *Element) bool {
+	typ, _ := elem.Attr("type")
+	return strings.EqualFold(typ, "radio")
+}
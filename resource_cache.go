@@ -0,0 +1,213 @@
+package gohl
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultResourceCacheSize 每个窗口资源缓存的默认字节上限
+const DefaultResourceCacheSize = 32 << 20
+
+// resourceGeneration 资源来源的版本号，注册加载器或修改 Overlay 时递增，
+// 缓存中版本号较旧的条目视为失效
+var resourceGeneration atomic.Uint64
+
+// invalidateResourceCaches 使所有窗口中已缓存的资源失效
+func invalidateResourceCaches() {
+	resourceGeneration.Add(1)
+}
+
+// ResourceCacheStats 资源缓存统计
+type ResourceCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Entries   int
+	Bytes     int64
+	Pinned    int // 已交给 HTMLayout、尚未收到 HLN_DATA_LOADED 的资源数
+
+	// PinnedBytes 已交给 HTMLayout 且不在缓存中的数据的字节数，与 Bytes 一起计入缓存上限
+	PinnedBytes int64
+}
+
+type resourceEntry struct {
+	uri        string
+	data       []byte
+	dataType   uint32
+	generation uint64
+}
+
+// pinnedResource 同一 URI 交给 HTMLayout 的数据，每份不同的数据都保留到该 URI 的引用全部释放
+type pinnedResource struct {
+	bufs  [][]byte
+	refs  int
+	bytes int64 // 计入 pinnedBytes 的字节数
+}
+
+// resourceCache 窗口的资源缓存：按字节上限做 LRU 淘汰；
+// 交给 HTMLayout 的数据在收到 HLN_DATA_LOADED 之前一直被引用，不受淘汰影响，
+// 其中不在缓存中的部分占用缓存上限，使缓存淘汰更多条目
+type resourceCache struct {
+	mu          sync.Mutex
+	maxBytes    int64
+	bytes       int64
+	pinnedBytes int64
+	lru         *list.List // 最近使用的在前
+	entries     map[string]*list.Element
+	pinned      map[string]*pinnedResource
+
+	hits, misses, evictions uint64
+}
+
+// newResourceCache maxBytes 为 0 时使用默认上限，为负数时不缓存（数据仍会被引用到加载完成）
+func newResourceCache(maxBytes int64) *resourceCache {
+	if maxBytes == 0 {
+		maxBytes = DefaultResourceCacheSize
+	}
+	return &resourceCache{
+		maxBytes: maxBytes,
+		lru:      list.New(),
+		entries:  make(map[string]*list.Element),
+		pinned:   make(map[string]*pinnedResource),
+	}
+}
+
+// get 读取缓存，失效的条目会被移除
+func (c *resourceCache) get(uri string) ([]byte, uint32, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[uri]; ok {
+		entry := elem.Value.(*resourceEntry)
+		if entry.generation == resourceGeneration.Load() {
+			c.hits++
+			c.lru.MoveToFront(elem)
+			return entry.data, entry.dataType, true
+		}
+		c.removeLocked(elem)
+	}
+
+	c.misses++
+	return nil, 0, false
+}
+
+// put 写入缓存并按字节上限淘汰最久未使用的条目，超过上限的单个资源不缓存
+func (c *resourceCache) put(uri string, data []byte, dataType uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[uri]; ok {
+		c.removeLocked(elem)
+	}
+	size := int64(len(data))
+	if size > c.maxBytes {
+		return
+	}
+
+	entry := &resourceEntry{uri: uri, data: data, dataType: dataType, generation: resourceGeneration.Load()}
+	c.entries[uri] = c.lru.PushFront(entry)
+	c.bytes += size
+	c.evictLocked()
+}
+
+// evictLocked 淘汰最久未使用的条目，直到缓存和被引用的数据不超过上限
+func (c *resourceCache) evictLocked() {
+	for c.bytes+c.pinnedBytes > c.maxBytes && c.lru.Len() > 0 {
+		c.removeLocked(c.lru.Back())
+		c.evictions++
+	}
+}
+
+// pin 引用交给 HTMLayout 的数据，直到 unpin
+// 同一 URI 可能在收到 HLN_DATA_LOADED 之前再次加载，每份不同的数据都要保留
+func (c *resourceCache) pin(uri string, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pinned[uri]
+	if !ok {
+		p = &pinnedResource{}
+		c.pinned[uri] = p
+	}
+	p.refs++
+	for _, buf := range p.bufs {
+		if sameBuffer(buf, data) {
+			return
+		}
+	}
+	p.bufs = append(p.bufs, data)
+
+	// 缓存中的同一份数据已计入 bytes
+	if elem, ok := c.entries[uri]; ok && sameBuffer(elem.Value.(*resourceEntry).data, data) {
+		return
+	}
+	p.bytes += int64(len(data))
+	c.pinnedBytes += int64(len(data))
+	c.evictLocked()
+}
+
+// unpin HTMLayout 不再引用该数据（HLN_DATA_LOADED）
+func (c *resourceCache) unpin(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if p, ok := c.pinned[uri]; ok {
+		p.refs--
+		if p.refs <= 0 {
+			c.pinnedBytes -= p.bytes
+			delete(c.pinned, uri)
+		}
+	}
+}
+
+// sameBuffer 判断两个切片是否为同一份数据
+func sameBuffer(a, b []byte) bool {
+	return len(a) == len(b) && (len(a) == 0 || &a[0] == &b[0])
+}
+
+// clear 清空缓存；unpinAll 为 true 时同时释放所有引用（窗口销毁时）
+func (c *resourceCache) clear(unpinAll bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.bytes = 0
+	if unpinAll {
+		c.pinned = make(map[string]*pinnedResource)
+		c.pinnedBytes = 0
+	}
+}
+
+func (c *resourceCache) stats() ResourceCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return ResourceCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Entries:   len(c.entries),
+		Bytes:     c.bytes,
+		Pinned:    len(c.pinned),
+
+		PinnedBytes: c.pinnedBytes,
+	}
+}
+
+func (c *resourceCache) removeLocked(elem *list.Element) {
+	entry := c.lru.Remove(elem).(*resourceEntry)
+	delete(c.entries, entry.uri)
+	c.bytes -= int64(len(entry.data))
+}
+
+// ResourceCacheStats 获取窗口资源缓存的统计
+func (w *Window) ResourceCacheStats() ResourceCacheStats {
+	return w.resources.stats()
+}
+
+// ClearResourceCache 清空窗口的资源缓存，HTMLayout 正在使用的数据不受影响
+func (w *Window) ClearResourceCache() {
+	w.resources.clear(false)
+}
@@ -0,0 +1,189 @@
+package gohl
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrResourceBlocked 资源加载被 ResourcePolicy 拒绝
+var ErrResourceBlocked = errors.New("资源加载被拒绝")
+
+// ResourcePolicy 窗口的资源加载策略，对默认和自定义的 OnLoadData 都有效，被拒绝的请求返回 LOAD_DISCARD
+// 用于显示用户提供的 HTML 时限制页面能访问的文件和网络
+type ResourcePolicy struct {
+	AllowedSchemes []string // 允许的 scheme（不区分大小写），为空时不限制；使用 LoadFile 加载本地文件时需包含 "file"
+	AllowedHosts   []string // http/https 允许的主机，支持 "*.example.com"，为空时不限制
+	AppRoot        string   // 不为空时 file:// 和本地路径只能访问该目录下的文件
+	MaxSize        int64    // 单个资源的最大字节数，0 表示不限制；对 OnLoadData 交付的数据和本地文件有效
+
+	// OnBlocked 资源被拒绝时调用，err 包装了 ErrResourceBlocked；为 nil 时写入日志
+	OnBlocked func(uri string, err error)
+}
+
+// SetResourcePolicy 设置窗口的资源加载策略，nil 表示不限制
+func (w *Window) SetResourcePolicy(p *ResourcePolicy) *Window {
+	w.policy = p
+	return w
+}
+
+// ResourcePolicy 获取窗口的资源加载策略
+func (w *Window) ResourcePolicy() *ResourcePolicy {
+	return w.policy
+}
+
+// checkURI 检查 URI 的 scheme、主机和本地路径
+func (p *ResourcePolicy) checkURI(uri string) error {
+	scheme, rest, ok := strings.Cut(uri, ":")
+	if !ok || len(scheme) == 1 || strings.ContainsAny(scheme, "/\\") {
+		// 没有 scheme 的本地路径（包括 C:/... 形式）
+		scheme, rest = "file", uri
+	} else {
+		rest = strings.TrimPrefix(rest, "//")
+	}
+	scheme = strings.ToLower(scheme)
+
+	if len(p.AllowedSchemes) > 0 && !containsFold(p.AllowedSchemes, scheme) {
+		return fmt.Errorf("%w: 不允许的 scheme %s", ErrResourceBlocked, scheme)
+	}
+
+	switch scheme {
+	case "http", "https":
+		if len(p.AllowedHosts) == 0 {
+			return nil
+		}
+		u, err := url.Parse(uri)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrResourceBlocked, err)
+		}
+		if !hostAllowed(p.AllowedHosts, u.Hostname()) {
+			return fmt.Errorf("%w: 不允许的主机 %s", ErrResourceBlocked, u.Hostname())
+		}
+	case "file":
+		if p.AppRoot == "" {
+			return nil
+		}
+		name, err := localResourcePath(rest)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrResourceBlocked, err)
+		}
+		if !pathWithin(p.AppRoot, name) {
+			return fmt.Errorf("%w: %s 不在应用目录中", ErrResourceBlocked, name)
+		}
+		if p.MaxSize > 0 {
+			if info, err := os.Stat(name); err == nil && info.Size() > p.MaxSize {
+				return fmt.Errorf("%w: %s 大小 %d 超过上限 %d", ErrResourceBlocked, name, info.Size(), p.MaxSize)
+			}
+		}
+	}
+	return nil
+}
+
+// checkSize 检查资源大小
+func (p *ResourcePolicy) checkSize(size int) error {
+	if p.MaxSize > 0 && int64(size) > p.MaxSize {
+		return fmt.Errorf("%w: 大小 %d 超过上限 %d", ErrResourceBlocked, size, p.MaxSize)
+	}
+	return nil
+}
+
+// allowResource 按窗口策略检查 URI，被拒绝时报告并返回 false
+func (w *Window) allowResource(uri string) bool {
+	if w.policy == nil {
+		return true
+	}
+	if err := w.policy.checkURI(uri); err != nil {
+		w.reportBlocked(uri, err)
+		return false
+	}
+	return true
+}
+
+// allowResourceSize 按窗口策略检查资源大小，被拒绝时报告并返回 false
+func (w *Window) allowResourceSize(uri string, size int) bool {
+	if w.policy == nil {
+		return true
+	}
+	if err := w.policy.checkSize(size); err != nil {
+		w.reportBlocked(uri, err)
+		return false
+	}
+	return true
+}
+
+func (w *Window) reportBlocked(uri string, err error) {
+	if w.policy.OnBlocked != nil {
+		w.policy.OnBlocked(uri, err)
+		return
+	}
+	log.Printf("[Resource] %s: %v", uri, err)
+}
+
+// localResourcePath 将 file:// 之后的部分（或本地路径）转换为绝对路径
+func localResourcePath(rest string) (string, error) {
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+	unescaped, err := url.PathUnescape(rest)
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.ReplaceAll(unescaped, "\\", "/")
+	// file:///C:/a 和 file://localhost/C:/a
+	if rest, ok := cutPrefixFold(name, "localhost/"); ok {
+		name = "/" + rest
+	}
+	if len(name) >= 3 && name[0] == '/' && name[2] == ':' {
+		name = name[1:]
+	}
+	return filepath.Abs(filepath.FromSlash(name))
+}
+
+// pathWithin 检查 name 是否在 root 目录中，存在的路径会先解析符号链接
+func pathWithin(root, name string) bool {
+	root, err := filepath.Abs(root)
+	if err != nil {
+		return false
+	}
+	if resolved, err := filepath.EvalSymlinks(root); err == nil {
+		root = resolved
+	}
+	if resolved, err := filepath.EvalSymlinks(name); err == nil {
+		name = resolved
+	}
+
+	rel, err := filepath.Rel(root, name)
+	if err != nil || filepath.IsAbs(rel) {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// hostAllowed 检查主机是否匹配列表中的任意一项，"*.example.com" 匹配其子域名
+func hostAllowed(hosts []string, host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range hosts {
+		pattern = strings.ToLower(pattern)
+		if pattern == host {
+			return true
+		}
+		if strings.HasPrefix(pattern, "*.") && strings.HasSuffix(host, pattern[1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,14 +3,22 @@ package gohl
 import (
 	"archive/zip"
 	"bytes"
+	"crypto/sha256"
 	"embed"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 )
 
-//go:embed resources.zip
+// resources.sha256 为 resources.zip 中每个文件的 SHA-256（sha256sum 格式），
+// 更新 resources.zip 时需要同步更新：sha256sum * > resources.sha256
+//
+//go:embed resources.zip resources.sha256
 var resourcesZip embed.FS
 
 var (
@@ -18,75 +26,354 @@ var (
 	resourcesExtracted bool
 )
 
-func extractResources() {
-	appData := os.Getenv("APPDATA")
-	if appData == "" {
-		appData = os.TempDir()
+const (
+	resourcesMarker    = ".extracted" // 解压完成标记，位于版本目录中
+	resourcesTmpSuffix = ".tmp-"      // 解压中的临时目录：<hash>.tmp-xxxx
+	resourcesTrashName = ".trash-"    // 待删除的旧版本目录
+	resourcesTmpMaxAge = time.Hour    // 超过该时间的临时目录视为中断的解压
+
+	// resourcesMaxAge 超过该时间没有被任何应用使用的版本会被自动删除，
+	// 每次启动都会刷新所用版本解压标记的修改时间，多个内置不同 resources.zip 的应用不会互相删除
+	resourcesMaxAge = 30 * 24 * time.Hour
+
+	maxResourceFileSize  = 64 << 20  // 单个文件解压后的最大大小
+	maxResourceTotalSize = 256 << 20 // 全部文件解压后的最大大小
+)
+
+// ErrResourceIntegrity 资源文件与清单中的 SHA-256 不一致
+var ErrResourceIntegrity = errors.New("资源文件校验失败")
+
+// resourcesBaseDir 所有资源版本的上级目录：<本机应用数据目录>/gohl
+// 每个版本解压到以 resources.zip 内容哈希命名的子目录中
+func resourcesBaseDir() string {
+	dir, err := ResolveAppDir(AppDirLocal, "gohl")
+	if err != nil {
+		dir = filepath.Join(os.TempDir(), "gohl")
 	}
+	return dir
+}
 
-	resourcesDir = filepath.Join(appData, "gohl")
-	os.MkdirAll(resourcesDir, 0755)
+// resourcesVersion 返回 resources.zip 内容哈希的前 16 位
+func resourcesVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
 
-	markerFile := filepath.Join(resourcesDir, ".extracted")
-	if _, err := os.Stat(markerFile); err == nil {
-		resourcesExtracted = true
-		return
+// resourceManifest 解析嵌入的 resources.sha256，返回 文件名 -> SHA-256
+func resourceManifest() (map[string]string, error) {
+	data, err := resourcesZip.ReadFile("resources.sha256")
+	if err != nil {
+		return nil, fmt.Errorf("读取资源清单失败: %w", err)
 	}
 
+	manifest := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("资源清单格式错误: %q", line)
+		}
+		manifest[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+	}
+	return manifest, nil
+}
+
+// verifyResourceFile 校验已解压的资源文件与清单是否一致
+func verifyResourceFile(path, name string) error {
+	manifest, err := resourceManifest()
+	if err != nil {
+		return err
+	}
+	want, ok := manifest[name]
+	if !ok {
+		return fmt.Errorf("%w: %s 不在清单中", ErrResourceIntegrity, name)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return fmt.Errorf("%w: %s 的 SHA-256 为 %s，应为 %s", ErrResourceIntegrity, name, got, want)
+	}
+	return nil
+}
+
+// extractResources 将嵌入的 resources.zip 解压到 baseDir 中以内容哈希命名的版本目录
+// 解压在临时目录中进行，全部文件校验通过后才重命名为版本目录，失败时不留下任何文件
+func extractResources(baseDir string) error {
 	data, err := resourcesZip.ReadFile("resources.zip")
 	if err != nil {
-		fmt.Printf("读取嵌入的 resources.zip 失败: %v\n", err)
-		return
+		return fmt.Errorf("读取嵌入的 resources.zip 失败: %w", err)
 	}
 
+	version := resourcesVersion(data)
+	versionDir := filepath.Join(baseDir, version)
+	resourcesDir = versionDir
+
+	if _, err := os.Stat(filepath.Join(versionDir, resourcesMarker)); err == nil {
+		resourcesExtracted = true
+		// 记录最近使用时间，避免被其他应用当作旧版本清理
+		now := time.Now()
+		os.Chtimes(filepath.Join(versionDir, resourcesMarker), now, now)
+		gcResourceVersions(baseDir, version)
+		return nil
+	}
+
+	manifest, err := resourceManifest()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(baseDir, 0755); err != nil {
+		return fmt.Errorf("创建资源目录失败: %w", err)
+	}
+
+	// 先解压到临时目录，完成后整体重命名，其他进程不会看到解压了一半的目录
+	tmpDir, err := os.MkdirTemp(baseDir, version+resourcesTmpSuffix)
+	if err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+
+	if err := unzipResources(data, tmpDir, manifest); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+
+	marker, err := os.Create(filepath.Join(tmpDir, resourcesMarker))
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return fmt.Errorf("写入解压标记失败: %w", err)
+	}
+	marker.Close()
+
+	if err := os.Rename(tmpDir, versionDir); err != nil {
+		os.RemoveAll(tmpDir)
+		// 其他进程可能已经抢先完成了同一版本的解压
+		if _, statErr := os.Stat(filepath.Join(versionDir, resourcesMarker)); statErr != nil {
+			return fmt.Errorf("重命名资源目录失败: %w", err)
+		}
+	}
+
+	resourcesExtracted = true
+	logf("资源已解压到: %s", versionDir)
+
+	gcResourceVersions(baseDir, version)
+	return nil
+}
+
+// unzipResources 解压到 dir，拒绝路径越界、超过大小限制以及与清单不一致的文件
+func unzipResources(data []byte, dir string, manifest map[string]string) error {
 	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		fmt.Printf("解压 resources.zip 失败: %v\n", err)
-		return
+		return fmt.Errorf("解压 resources.zip 失败: %w", err)
 	}
 
+	var total int64
+	extracted := make(map[string]bool, len(manifest))
 	for _, file := range reader.File {
-		dstPath := filepath.Join(resourcesDir, file.Name)
+		// zip 中的路径总是使用 /，拒绝绝对路径和 .. 等越界路径
+		name := strings.TrimSuffix(file.Name, "/")
+		if !filepath.IsLocal(filepath.FromSlash(name)) || strings.Contains(name, "\\") {
+			return fmt.Errorf("resources.zip 包含非法路径: %q", file.Name)
+		}
+		dstPath := filepath.Join(dir, filepath.FromSlash(name))
 
 		if file.FileInfo().IsDir() {
-			os.MkdirAll(dstPath, file.Mode())
+			if err := os.MkdirAll(dstPath, 0755); err != nil {
+				return fmt.Errorf("创建目录 %s 失败: %w", dstPath, err)
+			}
 			continue
 		}
+		if !file.Mode().IsRegular() {
+			return fmt.Errorf("resources.zip 包含非普通文件: %q", file.Name)
+		}
 
-		os.MkdirAll(filepath.Dir(dstPath), 0755)
+		want, ok := manifest[name]
+		if !ok {
+			return fmt.Errorf("%w: %s 不在清单中", ErrResourceIntegrity, name)
+		}
 
-		srcFile, err := file.Open()
+		n, sum, err := extractResourceFile(file, dstPath, maxResourceTotalSize-total)
 		if err != nil {
-			fmt.Printf("打开压缩文件 %s 失败: %v\n", file.Name, err)
-			continue
+			return err
 		}
+		total += n
+		if sum != want {
+			return fmt.Errorf("%w: %s 的 SHA-256 为 %s，应为 %s", ErrResourceIntegrity, name, sum, want)
+		}
+		extracted[name] = true
+	}
 
-		dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, file.Mode())
-		if err != nil {
-			srcFile.Close()
-			fmt.Printf("创建文件 %s 失败: %v\n", dstPath, err)
-			continue
+	for name := range manifest {
+		if !extracted[name] {
+			return fmt.Errorf("%w: resources.zip 缺少 %s", ErrResourceIntegrity, name)
 		}
+	}
+	return nil
+}
 
-		_, err = io.Copy(dstFile, srcFile)
-		srcFile.Close()
-		dstFile.Close()
+// extractResourceFile 解压单个文件，最多写入 min(maxResourceFileSize, remaining) 字节，
+// 返回写入的字节数和 SHA-256
+func extractResourceFile(file *zip.File, dstPath string, remaining int64) (int64, string, error) {
+	limit := int64(maxResourceFileSize)
+	if remaining < limit {
+		limit = remaining
+	}
+	if file.UncompressedSize64 > uint64(limit) {
+		return 0, "", fmt.Errorf("%s 超过大小限制", file.Name)
+	}
 
-		if err != nil {
-			fmt.Printf("写入文件 %s 失败: %v\n", dstPath, err)
+	if err := os.MkdirAll(filepath.Dir(dstPath), 0755); err != nil {
+		return 0, "", fmt.Errorf("创建目录失败: %w", err)
+	}
+
+	srcFile, err := file.Open()
+	if err != nil {
+		return 0, "", fmt.Errorf("打开压缩文件 %s 失败: %w", file.Name, err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.OpenFile(dstPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return 0, "", fmt.Errorf("创建文件 %s 失败: %w", dstPath, err)
+	}
+
+	// 声明的大小可能是伪造的，按实际解压的字节数再检查一次
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(dstFile, h), io.LimitReader(srcFile, limit+1))
+	if closeErr := dstFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return n, "", fmt.Errorf("写入文件 %s 失败: %w", dstPath, err)
+	}
+	if n > limit {
+		return n, "", fmt.Errorf("%s 超过大小限制", file.Name)
+	}
+
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// gcResourceVersions 删除超过 resourcesMaxAge 未使用的旧版本、中断的解压目录和旧版本的资源目录，失败时只打印日志
+func gcResourceVersions(baseDir, keep string) {
+	for _, err := range removeResourceVersions(baseDir, keep, resourcesMaxAge) {
+		logf("清理旧版本资源失败: %v", err)
+	}
+	if err := removeLegacyResources(); err != nil {
+		logf("清理旧版本资源失败: %v", err)
+	}
+}
+
+// removeResourceVersions 删除 baseDir 中 keep 以外、最近使用时间早于 maxAge 的版本目录（maxAge 为 0 时不限），
+// 返回删除失败的错误
+// 正在被其他 gohl 应用使用的版本（htmlayout.dll 已加载）无法重命名，会被跳过
+func removeResourceVersions(baseDir, keep string, maxAge time.Duration) []error {
+	entries, err := os.ReadDir(baseDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return []error{err}
+	}
+
+	var errs []error
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || name == keep {
 			continue
 		}
 
-		fmt.Printf("释放资源: %s\n", dstPath)
+		path := filepath.Join(baseDir, name)
+		switch {
+		case strings.Contains(name, resourcesTmpSuffix):
+			// 解压中的临时目录可能属于其他进程，只清理超时的
+			info, err := entry.Info()
+			if err != nil || time.Since(info.ModTime()) < resourcesTmpMaxAge {
+				continue
+			}
+		case strings.HasPrefix(name, resourcesTrashName):
+			// 上次没有删完的旧版本，直接删除
+		case maxAge > 0:
+			// 以解压标记的修改时间作为最近使用时间，没有标记时使用目录的修改时间
+			info, err := os.Stat(filepath.Join(path, resourcesMarker))
+			if err != nil {
+				info, err = entry.Info()
+			}
+			if err != nil || time.Since(info.ModTime()) < maxAge {
+				continue
+			}
+		}
+
+		// 先重命名再删除：版本目录中有文件被占用时重命名会失败，
+		// 避免删掉其他应用正在使用的版本中的部分文件
+		trash := path
+		if !strings.HasPrefix(name, resourcesTrashName) {
+			trash = filepath.Join(baseDir, resourcesTrashName+name)
+			if err := os.Rename(path, trash); err != nil {
+				continue
+			}
+		}
+		if err := os.RemoveAll(trash); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// removeLegacyResources 删除早期版本解压到 <漫游配置目录>/gohl 中的资源文件
+// 该目录可能同时保存了名为 gohl 的应用数据，因此只删除清单中的文件和解压标记，目录为空时才删除目录
+func removeLegacyResources() error {
+	dir, err := ResolveAppDir(AppDirRoaming, "gohl")
+	if err != nil {
+		return nil
+	}
+	marker := filepath.Join(dir, resourcesMarker)
+	if _, err := os.Stat(marker); err != nil {
+		return nil
 	}
 
-	marker, err := os.Create(markerFile)
-	if err == nil {
-		marker.Close()
+	manifest, err := resourceManifest()
+	if err != nil {
+		return err
+	}
+	for name := range manifest {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			// 文件被仍在运行的旧版本应用占用，保留标记以便下次重试
+			return err
+		}
 	}
 
-	resourcesExtracted = true
-	fmt.Printf("资源已解压到: %s\n", resourcesDir)
+	if err := os.Remove(marker); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	os.Remove(dir)
+	return nil
+}
+
+// CleanupResources 立即删除当前版本以外的所有已解压资源版本（包括其他 gohl 应用最近使用的版本）和旧版本的资源目录，
+// 正在被其他 gohl 应用使用的版本会被跳过，其他应用下次启动时会重新解压
+func CleanupResources() error {
+	baseDir, keep := resourcesBaseDir(), ""
+	if resourcesExtracted {
+		baseDir, keep = filepath.Dir(resourcesDir), filepath.Base(resourcesDir)
+	}
+	errs := removeResourceVersions(baseDir, keep, 0)
+	if err := removeLegacyResources(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
 
 func GetResourcesDir() string {
@@ -0,0 +1,101 @@
+package gohl
+
+import (
+	"context"
+	"log"
+	"strings"
+)
+
+// AsyncResourceLoader 异步资源加载器，在独立的 goroutine 中执行；
+// 窗口关闭时 ctx 被取消，加载器应尽快返回
+type AsyncResourceLoader func(ctx context.Context, uri string) ([]byte, error)
+
+// asyncLoader 已注册的异步加载器及其占位图
+type asyncLoader struct {
+	load        AsyncResourceLoader
+	placeholder []byte
+}
+
+// asyncLoaders 与 resourceLoaders 共用 resourceLoadersMu
+var asyncLoaders = make(map[string]asyncLoader)
+
+// RegisterAsyncResourceLoader 注册 scheme:// 资源的异步加载器，scheme 不区分大小写；loader 为 nil 时取消注册
+// HLN_LOAD_DATA 立即返回，数据加载完成后在 UI 线程通过 DataReady 交给 HTMLayout
+// placeholder 不为空时，图片在加载完成前先显示该占位图
+// 同一 scheme 的同步加载器（RegisterResourceLoader/RegisterFS）会被替换
+func RegisterAsyncResourceLoader(scheme string, loader AsyncResourceLoader, placeholder []byte) {
+	resourceLoadersMu.Lock()
+	defer resourceLoadersMu.Unlock()
+
+	scheme = strings.ToLower(scheme)
+	invalidateResourceCaches()
+	delete(resourceLoaders, scheme)
+	delete(uncachedSchemes, scheme)
+	if loader == nil {
+		delete(asyncLoaders, scheme)
+		return
+	}
+	asyncLoaders[scheme] = asyncLoader{load: loader, placeholder: placeholder}
+}
+
+// asyncLoaderFor 按 URI 的 scheme 查找异步加载器
+func asyncLoaderFor(uri string) (asyncLoader, bool) {
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok {
+		return asyncLoader{}, false
+	}
+
+	resourceLoadersMu.RLock()
+	defer resourceLoadersMu.RUnlock()
+
+	loader, ok := asyncLoaders[strings.ToLower(scheme)]
+	return loader, ok
+}
+
+// loadAsync 启动异步加载并返回 LOAD_DELAYED（在 UI 线程调用）
+// 同一 URI 正在加载时不重复请求，数据就绪后由 DataReady 一并交付
+func (w *Window) loadAsync(params *NmhlLoadData, uri string, loader asyncLoader) uintptr {
+	if w.ctx.Err() != nil {
+		return LOAD_DISCARD
+	}
+
+	// 占位图先于加载结果进入 Dispatcher 队列，保证不会覆盖真实数据
+	if len(loader.placeholder) > 0 && params.DataType == HLRT_DATA_IMAGE {
+		placeholder := loader.placeholder
+		w.Dispatch(func() {
+			if w.ctx.Err() == nil && w.pendingLoads[uri] {
+				DataReady(w.hwnd, stringToUtf16Ptr(uri), placeholder)
+			}
+		})
+	}
+
+	if w.pendingLoads[uri] {
+		return LOAD_DELAYED
+	}
+	w.pendingLoads[uri] = true
+
+	ctx := w.ctx
+	go func() {
+		data, err := loader.load(ctx, uri)
+		if ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			log.Printf("[Resource] 异步加载 %s 失败: %v", uri, err)
+		}
+
+		w.Dispatch(func() {
+			delete(w.pendingLoads, uri)
+			if ctx.Err() != nil || err != nil || len(data) == 0 || !w.allowResourceSize(uri, len(data)) {
+				return
+			}
+			if windowCacheable(uri) {
+				w.resources.put(uri, data, DetectResourceDataType(uri, data, HLRT_DATA_RAW))
+			}
+			// DataReady 同步复制数据，无需等待 HLN_DATA_LOADED
+			DataReady(w.hwnd, stringToUtf16Ptr(uri), data)
+		})
+	}()
+
+	return LOAD_DELAYED
+}
@@ -0,0 +1,66 @@
+package gohl
+
+import (
+	"io/fs"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// RegisterFS 将 fsys 注册为 scheme:// 资源，embed.FS、zip.Reader、os.DirFS、fstest.MapFS 等均可使用
+// scheme://a/b.css 对应 fsys 中的 a/b.css；目录返回其中的 index.html；数据类型由扩展名决定
+func RegisterFS(scheme string, fsys fs.FS) {
+	RegisterResourceLoader(scheme, FSLoader(scheme, fsys))
+}
+
+// FSLoader 返回从 fsys 读取 scheme:// 资源的 ResourceLoader
+func FSLoader(scheme string, fsys fs.FS) ResourceLoader {
+	return func(uri string) ([]byte, uint32, bool) {
+		name, ok := fsResourceName(scheme, uri)
+		if !ok {
+			return nil, 0, false
+		}
+
+		if info, err := fs.Stat(fsys, name); err == nil && info.IsDir() {
+			name = path.Join(name, "index.html")
+		}
+
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, 0, false
+		}
+		return data, resourceDataTypeByExt(name), true
+	}
+}
+
+// fsResourceName 将 scheme://path 转换为 fs.FS 中的路径
+// 去掉查询参数和锚点，解码 %XX，并解析 . 和 ..（不会越出根目录）
+func fsResourceName(scheme, uri string) (string, bool) {
+	rest, ok := cutPrefixFold(uri, scheme+"://")
+	if !ok {
+		return "", false
+	}
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		rest = rest[:i]
+	}
+
+	unescaped, err := url.PathUnescape(rest)
+	if err != nil {
+		return "", false
+	}
+
+	// path.Clean 以 / 为根解析 ..，结果不会越出根目录
+	name := strings.TrimPrefix(path.Clean("/"+strings.ReplaceAll(unescaped, "\\", "/")), "/")
+	if name == "" {
+		name = "."
+	}
+	return name, fs.ValidPath(name)
+}
+
+// cutPrefixFold 忽略大小写地去掉前缀
+func cutPrefixFold(s, prefix string) (string, bool) {
+	if len(s) < len(prefix) || !strings.EqualFold(s[:len(prefix)], prefix) {
+		return s, false
+	}
+	return s[len(prefix):], true
+}
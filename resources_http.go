@@ -0,0 +1,146 @@
+package gohl
+
+import (
+	"bytes"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// maxResourceRedirects 处理 scheme:// 请求时最多跟随的重定向次数
+const maxResourceRedirects = 10
+
+// RegisterHandler 将 http.Handler 注册为 scheme:// 资源，请求在进程内处理，不监听端口
+// scheme://a/b?x=1 对应请求 GET /a/b?x=1，Host 为 scheme；可直接使用 http.ServeMux、路由库和 html/template
+// 只有 2xx 响应会交给 HTMLayout，数据类型由 Content-Type 决定，未设置时按扩展名判断
+// 响应不进入窗口缓存，每次加载都会调用 h，页面内容可以是动态的
+func RegisterHandler(scheme string, h http.Handler) {
+	registerResourceLoader(scheme, HandlerLoader(scheme, h), false)
+}
+
+// HandlerLoader 返回通过 h 处理 scheme:// 资源请求的 ResourceLoader
+func HandlerLoader(scheme string, h http.Handler) ResourceLoader {
+	return func(uri string) ([]byte, uint32, bool) {
+		rest, ok := cutPrefixFold(uri, scheme+"://")
+		if !ok {
+			return nil, 0, false
+		}
+		if i := strings.IndexByte(rest, '#'); i >= 0 {
+			rest = rest[:i]
+		}
+		target := "/" + strings.TrimPrefix(rest, "/")
+
+		// 跟随处理函数内部的重定向，例如 http.FileServer 将 /index.html 重定向到 /
+		for redirects := 0; ; redirects++ {
+			req, err := http.NewRequest(http.MethodGet, target, nil)
+			if err != nil {
+				log.Printf("[Resource] 无效的请求 %s: %v", uri, err)
+				return nil, 0, false
+			}
+			req.Host = strings.ToLower(scheme)
+			req.RequestURI = target
+
+			rw := newResourceResponseWriter()
+			if !serveResource(h, rw, req, uri) {
+				return nil, 0, false
+			}
+
+			location := rw.header.Get("Location")
+			if rw.status >= 300 && rw.status <= 399 && location != "" && redirects < maxResourceRedirects {
+				next, err := req.URL.Parse(location)
+				if err != nil || (next.Host != "" && !strings.EqualFold(next.Host, req.Host)) {
+					log.Printf("[Resource] %s 重定向到不支持的地址: %s", uri, location)
+					return nil, 0, false
+				}
+				target = next.RequestURI()
+				continue
+			}
+
+			if rw.status < 200 || rw.status > 299 {
+				log.Printf("[Resource] %s 返回 %d", uri, rw.status)
+				return nil, 0, false
+			}
+			return rw.body.Bytes(), contentTypeDataType(rw.header.Get("Content-Type"), req.URL.Path), true
+		}
+	}
+}
+
+// serveResource 调用 h 处理请求，与 net/http 一样从 panic 中恢复，避免处理函数崩溃 UI 线程
+func serveResource(h http.Handler, rw *resourceResponseWriter, req *http.Request, uri string) (ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if r != http.ErrAbortHandler {
+				log.Printf("[Resource] 处理 %s 时发生 panic: %v", uri, r)
+			}
+			ok = false
+		}
+	}()
+
+	h.ServeHTTP(rw, req)
+	rw.WriteHeader(http.StatusOK)
+	return true
+}
+
+// contentTypeDataType 将 Content-Type 映射为 HLRT_DATA_* 类型，无法识别时按 name 的扩展名判断
+func contentTypeDataType(contentType, name string) uint32 {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return resourceDataTypeByExt(name)
+	}
+
+	switch {
+	case mediaType == "text/html", mediaType == "application/xhtml+xml":
+		return HLRT_DATA_HTML
+	case mediaType == "text/css":
+		return HLRT_DATA_STYLE
+	case mediaType == "image/x-win-bitmap", mediaType == "image/x-cursor":
+		return HLRT_DATA_CURSOR
+	case strings.HasPrefix(mediaType, "image/"):
+		return HLRT_DATA_IMAGE
+	case strings.HasSuffix(mediaType, "javascript"), strings.HasSuffix(mediaType, "tiscript"):
+		return HLRT_DATA_SCRIPT
+	default:
+		return resourceDataTypeByExt(name)
+	}
+}
+
+// resourceResponseWriter 在内存中记录响应的 http.ResponseWriter
+type resourceResponseWriter struct {
+	header      http.Header
+	body        bytes.Buffer
+	status      int
+	wroteHeader bool
+}
+
+func newResourceResponseWriter() *resourceResponseWriter {
+	return &resourceResponseWriter{header: make(http.Header)}
+}
+
+// Header 响应头
+func (rw *resourceResponseWriter) Header() http.Header {
+	return rw.header
+}
+
+// WriteHeader 记录状态码，只有第一次调用有效
+func (rw *resourceResponseWriter) WriteHeader(status int) {
+	if rw.wroteHeader {
+		return
+	}
+	rw.wroteHeader = true
+	rw.status = status
+}
+
+// Write 写入响应体；与 net/http 一样，未设置 Content-Type 时根据内容推断
+func (rw *resourceResponseWriter) Write(p []byte) (int, error) {
+	if !rw.wroteHeader {
+		if rw.header.Get("Content-Type") == "" && rw.body.Len() == 0 {
+			rw.header.Set("Content-Type", http.DetectContentType(p))
+		}
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.body.Write(p)
+}
+
+// Flush 实现 http.Flusher，数据已在内存中，无需处理
+func (rw *resourceResponseWriter) Flush() {}
@@ -0,0 +1,198 @@
+package gohl
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ResourceMiddleware 资源加载中间件，包装下一层加载器
+type ResourceMiddleware func(next ResourceLoader) ResourceLoader
+
+var (
+	resourceMiddlewareMu sync.RWMutex
+	resourceMiddlewares  []ResourceMiddleware
+	resourceChainLoader  ResourceLoader = loadResourceDirect
+
+	// resourceChainValidates 中间件链中有带 etag 的 ResourceCaching，每次加载都需要经过中间件链校验
+	resourceChainValidates atomic.Bool
+)
+
+// UseResourceMiddleware 添加资源中间件，作用于所有同步加载的资源（RegisterResourceLoader、RegisterFS、
+// RegisterHandler 和 resources://）；先添加的在外层，异步加载器不经过中间件
+// 窗口缓存位于中间件链之前，命中时不经过中间件；添加了带 etag 的 ResourceCaching 时窗口缓存不再使用，
+// 每次加载都经过中间件链以便校验标签
+func UseResourceMiddleware(mw ...ResourceMiddleware) {
+	resourceMiddlewareMu.Lock()
+	defer resourceMiddlewareMu.Unlock()
+
+	resourceMiddlewares = append(resourceMiddlewares, mw...)
+	loader := ResourceLoader(loadResourceDirect)
+	for i := len(resourceMiddlewares) - 1; i >= 0; i-- {
+		loader = resourceMiddlewares[i](loader)
+	}
+	resourceChainLoader = loader
+	invalidateResourceCaches()
+}
+
+// resourceChain 当前的中间件链
+func resourceChain() ResourceLoader {
+	resourceMiddlewareMu.RLock()
+	defer resourceMiddlewareMu.RUnlock()
+
+	return resourceChainLoader
+}
+
+// resourceChainValidating 中间件链是否需要在每次加载时校验资源（带 etag 的 ResourceCaching）
+func resourceChainValidating() bool {
+	return resourceChainValidates.Load()
+}
+
+// ResourceLogging 记录每次加载的 URI、大小和耗时，logger 为 nil 时使用 log.Default()
+func ResourceLogging(logger *log.Logger) ResourceMiddleware {
+	if logger == nil {
+		logger = log.Default()
+	}
+	return func(next ResourceLoader) ResourceLoader {
+		return func(uri string) ([]byte, uint32, bool) {
+			start := time.Now()
+			data, dataType, ok := next(uri)
+			if ok {
+				logger.Printf("[Resource] %s %d bytes type=%d %v", uri, len(data), dataType, time.Since(start))
+			} else {
+				logger.Printf("[Resource] %s not found %v", uri, time.Since(start))
+			}
+			return data, dataType, ok
+		}
+	}
+}
+
+// ResourceCaching 在所有窗口间共享的内存缓存，按字节上限做 LRU 淘汰（maxBytes 为 0 时使用 DefaultResourceCacheSize）
+// etag 不为 nil 时，每次加载先计算资源的当前标签（如文件的修改时间），标签变化后重新加载；
+// 返回空字符串表示不缓存该资源；此时窗口缓存不再使用，每次加载都会经过中间件链
+func ResourceCaching(maxBytes int64, etag func(uri string) string) ResourceMiddleware {
+	cache := newResourceCache(maxBytes)
+	return func(next ResourceLoader) ResourceLoader {
+		if etag != nil {
+			resourceChainValidates.Store(true)
+		}
+		return func(uri string) ([]byte, uint32, bool) {
+			key := uri
+			if etag != nil {
+				tag := etag(uri)
+				if tag == "" {
+					return next(uri)
+				}
+				key = uri + "\x00" + tag
+			}
+
+			if data, dataType, ok := cache.get(key); ok {
+				return data, dataType, true
+			}
+			data, dataType, ok := next(uri)
+			if ok {
+				cache.put(key, data, dataType)
+			}
+			return data, dataType, ok
+		}
+	}
+}
+
+// ResourceGzip 支持 gzip 压缩的资源：数据以 gzip 头开始时自动解压；
+// 找不到 uri 时尝试 uri.gz，数据类型仍按原 uri 判断
+func ResourceGzip() ResourceMiddleware {
+	return func(next ResourceLoader) ResourceLoader {
+		return func(uri string) ([]byte, uint32, bool) {
+			data, dataType, ok := next(uri)
+			if !ok {
+				path, suffix := splitResourceQuery(uri)
+				if data, _, ok = next(path + ".gz" + suffix); !ok {
+					return nil, 0, false
+				}
+				dataType = resourceDataTypeByExt(path)
+			}
+
+			if !isGzipData(data) {
+				return data, dataType, true
+			}
+			decoded, err := gunzipResource(data)
+			if err != nil {
+				log.Printf("[Resource] 解压 %s 失败: %v", uri, err)
+				return nil, 0, false
+			}
+			return decoded, dataType, true
+		}
+	}
+}
+
+// ResourceSubstitute 在 HTML 和 CSS 资源中按字面替换字符串，例如 {"@VERSION@": "1.2.0", "--theme-accent": "#0a84ff"}
+func ResourceSubstitute(replacements map[string]string) ResourceMiddleware {
+	// 按长度从长到短排列，重叠的键（如 "@V@" 和 "@V@2"）总是优先匹配较长的，结果与 map 的遍历顺序无关
+	keys := make([]string, 0, len(replacements))
+	for from := range replacements {
+		keys = append(keys, from)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if len(keys[i]) != len(keys[j]) {
+			return len(keys[i]) > len(keys[j])
+		}
+		return keys[i] < keys[j]
+	})
+	pairs := make([]string, 0, len(keys)*2)
+	for _, from := range keys {
+		pairs = append(pairs, from, replacements[from])
+	}
+	replacer := strings.NewReplacer(pairs...)
+
+	return func(next ResourceLoader) ResourceLoader {
+		return func(uri string) ([]byte, uint32, bool) {
+			data, dataType, ok := next(uri)
+			if !ok || len(pairs) == 0 || (dataType != HLRT_DATA_HTML && dataType != HLRT_DATA_STYLE) {
+				return data, dataType, ok
+			}
+			return []byte(replacer.Replace(string(data))), dataType, true
+		}
+	}
+}
+
+// splitResourceQuery 将 URI 拆分为路径和 ?query#fragment 部分
+func splitResourceQuery(uri string) (string, string) {
+	scheme, rest, ok := strings.Cut(uri, "://")
+	if !ok {
+		scheme, rest = "", uri
+	} else {
+		scheme += "://"
+	}
+	if i := strings.IndexAny(rest, "?#"); i >= 0 {
+		return scheme + rest[:i], rest[i:]
+	}
+	return uri, ""
+}
+
+func isGzipData(data []byte) bool {
+	return len(data) >= 2 && data[0] == 0x1f && data[1] == 0x8b
+}
+
+func gunzipResource(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+
+	data, err = io.ReadAll(io.LimitReader(zr, maxResourceFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResourceFileSize {
+		return nil, fmt.Errorf("解压后超过 %d 字节", maxResourceFileSize)
+	}
+	return data, nil
+}
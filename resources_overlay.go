@@ -0,0 +1,119 @@
+package gohl
+
+import (
+	"archive/zip"
+	"bytes"
+	"io/fs"
+	"sync"
+)
+
+// Overlay 由多个资源源叠加而成的 fs.FS，按顺序查找，先找到的优先
+// 资源源可以在运行时增删，配合 RegisterFS 使用：
+//
+//	overlay := gohl.NewOverlay()
+//	overlay.Add("theme", os.DirFS(themeDir))
+//	overlay.Add("app", appFS)
+//	gohl.RegisterFS("app", overlay)
+type Overlay struct {
+	mu      sync.RWMutex
+	sources []overlaySource // 写时复制，查找时无需持锁
+}
+
+type overlaySource struct {
+	name string
+	fsys fs.FS
+}
+
+// NewOverlay 创建空的 Overlay
+func NewOverlay() *Overlay {
+	return &Overlay{}
+}
+
+// Add 添加优先级最低的资源源，同名的资源源会被替换
+func (o *Overlay) Add(name string, fsys fs.FS) {
+	o.Insert(-1, name, fsys)
+}
+
+// Insert 将资源源插入到第 index 位（0 为最高优先级，超出范围或为负数时添加到末尾），
+// 同名的资源源会先被移除
+func (o *Overlay) Insert(index int, name string, fsys fs.FS) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	sources := make([]overlaySource, 0, len(o.sources)+1)
+	for _, src := range o.sources {
+		if src.name != name {
+			sources = append(sources, src)
+		}
+	}
+	if index < 0 || index > len(sources) {
+		index = len(sources)
+	}
+	sources = append(sources, overlaySource{})
+	copy(sources[index+1:], sources[index:])
+	sources[index] = overlaySource{name: name, fsys: fsys}
+
+	o.sources = sources
+	invalidateResourceCaches()
+}
+
+// Remove 移除资源源，不存在时返回 false
+func (o *Overlay) Remove(name string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	sources := make([]overlaySource, 0, len(o.sources))
+	for _, src := range o.sources {
+		if src.name != name {
+			sources = append(sources, src)
+		}
+	}
+	if len(sources) == len(o.sources) {
+		return false
+	}
+
+	o.sources = sources
+	invalidateResourceCaches()
+	return true
+}
+
+// Sources 按优先级返回资源源名称
+func (o *Overlay) Sources() []string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	names := make([]string, len(o.sources))
+	for i, src := range o.sources {
+		names[i] = src.name
+	}
+	return names
+}
+
+// Open 实现 fs.FS，返回第一个包含 name 的资源源中的文件
+// 某个资源源打开失败（如权限错误）时继续查找下一个
+func (o *Overlay) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	o.mu.RLock()
+	sources := o.sources
+	o.mu.RUnlock()
+
+	for _, src := range sources {
+		if f, err := src.fsys.Open(name); err == nil {
+			return f, nil
+		}
+	}
+
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// BuiltinFS 返回内置的 resources.zip（htmlayout.dll 和字体），可作为 Overlay 的最后一层
+func BuiltinFS() (fs.FS, error) {
+	data, err := resourcesZip.ReadFile("resources.zip")
+	if err != nil {
+		return nil, err
+	}
+	return zip.NewReader(bytes.NewReader(data), int64(len(data)))
+}
@@ -0,0 +1,148 @@
+package gohl
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"sync"
+)
+
+// HLRT_DATA_RAW gohl 扩展的资源类型，表示字体等 HTMLayout 没有对应类型的数据，
+// 不会传给 HTMLayout，交付时使用请求方期望的类型
+const HLRT_DATA_RAW = 0xFF
+
+// ResourceTypeOverride 按 URL 指定资源类型，返回 false 时使用默认的判断
+type ResourceTypeOverride func(uri string, data []byte) (uint32, bool)
+
+var (
+	resourceTypesMu sync.RWMutex
+	resourceTypes   = map[string]uint32{
+		".html":  HLRT_DATA_HTML,
+		".htm":   HLRT_DATA_HTML,
+		".xhtml": HLRT_DATA_HTML,
+		".css":   HLRT_DATA_STYLE,
+		".js":    HLRT_DATA_SCRIPT,
+		".tis":   HLRT_DATA_SCRIPT,
+		".png":   HLRT_DATA_IMAGE,
+		".jpg":   HLRT_DATA_IMAGE,
+		".jpeg":  HLRT_DATA_IMAGE,
+		".gif":   HLRT_DATA_IMAGE,
+		".bmp":   HLRT_DATA_IMAGE,
+		".ico":   HLRT_DATA_IMAGE,
+		".webp":  HLRT_DATA_IMAGE,
+		".svg":   HLRT_DATA_IMAGE,
+		".cur":   HLRT_DATA_CURSOR,
+		".ttf":   HLRT_DATA_RAW,
+		".otf":   HLRT_DATA_RAW,
+		".ttc":   HLRT_DATA_RAW,
+		".woff":  HLRT_DATA_RAW,
+		".woff2": HLRT_DATA_RAW,
+	}
+	resourceTypeOverride ResourceTypeOverride
+)
+
+// RegisterResourceType 注册扩展名对应的资源类型，扩展名不区分大小写，可带或不带 "."
+func RegisterResourceType(ext string, dataType uint32) {
+	ext = strings.ToLower(ext)
+	if !strings.HasPrefix(ext, ".") {
+		ext = "." + ext
+	}
+
+	resourceTypesMu.Lock()
+	defer resourceTypesMu.Unlock()
+
+	resourceTypes[ext] = dataType
+	invalidateResourceCaches()
+}
+
+// SetResourceTypeOverride 设置按 URL 指定资源类型的钩子，优先于扩展名和内容判断；fn 为 nil 时取消
+func SetResourceTypeOverride(fn ResourceTypeOverride) {
+	resourceTypesMu.Lock()
+	defer resourceTypesMu.Unlock()
+
+	resourceTypeOverride = fn
+	invalidateResourceCaches()
+}
+
+// GetResourceDataType 根据扩展名返回可以交给 HTMLayout 的资源数据类型，忽略大小写、查询参数和锚点
+// 未注册的扩展名、无扩展名的 URL 以及字体等注册为 HLRT_DATA_RAW 的扩展名返回 HLRT_DATA_HTML
+func GetResourceDataType(filename string) uint32 {
+	if dataType := resourceDataTypeByExt(filename); dataType != HLRT_DATA_RAW {
+		return dataType
+	}
+	return HLRT_DATA_HTML
+}
+
+// resourceDataTypeByExt 根据扩展名返回资源数据类型，未注册的扩展名和无扩展名的 URL 返回 HLRT_DATA_RAW，
+// 由调用方继续按内容或请求方期望的类型判断
+func resourceDataTypeByExt(filename string) uint32 {
+	if i := strings.IndexAny(filename, "?#"); i >= 0 {
+		filename = filename[:i]
+	}
+	ext := strings.ToLower(path.Ext(strings.ReplaceAll(filename, "\\", "/")))
+
+	resourceTypesMu.RLock()
+	defer resourceTypesMu.RUnlock()
+
+	if dataType, ok := resourceTypes[ext]; ok {
+		return dataType
+	}
+	return HLRT_DATA_RAW
+}
+
+// DetectResourceDataType 确定资源的数据类型：依次使用 SetResourceTypeOverride 的钩子、
+// 加载器给出的类型、扩展名和数据内容
+// dataType 为 HLRT_DATA_RAW 时视为未知；为 HLRT_DATA_HTML 而 URI 不是已注册的 HTML 扩展名时也视为未知，
+// 因为 GetResourceDataType 对字体和未知类型同样返回 HLRT_DATA_HTML
+func DetectResourceDataType(uri string, data []byte, dataType uint32) uint32 {
+	resourceTypesMu.RLock()
+	override := resourceTypeOverride
+	resourceTypesMu.RUnlock()
+
+	if override != nil {
+		if t, ok := override(uri, data); ok {
+			return t
+		}
+	}
+	byExt := resourceDataTypeByExt(uri)
+	if dataType != HLRT_DATA_RAW && (dataType != HLRT_DATA_HTML || byExt == HLRT_DATA_HTML) {
+		return dataType
+	}
+	if byExt != HLRT_DATA_RAW {
+		return byExt
+	}
+	if t, ok := SniffResourceDataType(data); ok {
+		return t
+	}
+	return HLRT_DATA_RAW
+}
+
+// SniffResourceDataType 根据文件头识别图片（PNG/JPEG/GIF/BMP/ICO/WebP）、光标、字体和 HTML
+// 字体返回 HLRT_DATA_RAW；无法识别时返回 false
+func SniffResourceDataType(data []byte) (uint32, bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte("\x89PNG\r\n\x1a\n")),
+		bytes.HasPrefix(data, []byte("\xff\xd8\xff")),
+		bytes.HasPrefix(data, []byte("GIF87a")),
+		bytes.HasPrefix(data, []byte("GIF89a")),
+		bytes.HasPrefix(data, []byte("BM")) && len(data) >= 14,
+		bytes.HasPrefix(data, []byte("\x00\x00\x01\x00")),
+		len(data) >= 12 && bytes.Equal(data[:4], []byte("RIFF")) && bytes.Equal(data[8:12], []byte("WEBP")):
+		return HLRT_DATA_IMAGE, true
+	case bytes.HasPrefix(data, []byte("\x00\x00\x02\x00")):
+		return HLRT_DATA_CURSOR, true
+	case bytes.HasPrefix(data, []byte("\x00\x01\x00\x00")),
+		bytes.HasPrefix(data, []byte("OTTO")),
+		bytes.HasPrefix(data, []byte("true")),
+		bytes.HasPrefix(data, []byte("ttcf")),
+		bytes.HasPrefix(data, []byte("wOFF")),
+		bytes.HasPrefix(data, []byte("wOF2")):
+		return HLRT_DATA_RAW, true
+	}
+
+	text := bytes.TrimLeft(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), " \t\r\n")
+	if bytes.HasPrefix(text, []byte("<")) {
+		return HLRT_DATA_HTML, true
+	}
+	return 0, false
+}
@@ -1,11 +1,10 @@
 package gohl
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
+	"log"
 	"os"
-	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
@@ -15,6 +14,7 @@ type StorageItem struct {
 	Value      interface{}
 	ExpireAt   time.Time
 	HasTTL     bool
+	AccessedAt time.Time // 最近写入或（缓存模式下）读取的时间
 }
 
 // IsExpired 检查是否已过期
@@ -25,95 +25,120 @@ func (item *StorageItem) IsExpired() bool {
 	return time.Now().After(item.ExpireAt)
 }
 
+// ExpireHandler 过期回调，key 过期被清理时调用
+type ExpireHandler func(key string, value interface{})
+
+// expiredEntry 已被清理的过期项
+type expiredEntry struct {
+	key   string
+	value interface{}
+}
+
 // Storage 带TTL的本地KV存储
 type Storage struct {
 	data     map[string]*StorageItem
 	mu       sync.RWMutex
 	filePath string
 	dirty    bool
-}
 
-// NewStorage 创建新的存储实例
-func NewStorage(appName string) (*Storage, error) {
-	appDataDir := os.Getenv("APPDATA")
-	if appDataDir == "" {
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("无法获取用户目录: %w", err)
-		}
-		appDataDir = filepath.Join(homeDir, "AppData", "Roaming")
-	}
+	// changes 记录自上次保存以来的本地修改（nil 表示删除），保存时合并到磁盘上的最新数据
+	changes   map[string]*StorageItem
+	cleared   bool
+	fileToken uint64 // 上次读写时文件头中的写入标记，用于判断文件是否被其他进程改写
+	schema    int    // 文件的 schema 版本
+	backups   int    // 保存时保留的备份代数
 
-	dir := filepath.Join(appDataDir, appName)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("无法创建目录: %w", err)
-	}
+	quarantined string // 打开时主文件和备份均无法读取，被移到一旁的主文件
 
-	filePath := filepath.Join(dir, "storage.dat")
-	s := &Storage{
-		data:     make(map[string]*StorageItem),
-		filePath: filePath,
-		dirty:    false,
-	}
+	perm    os.FileMode // 数据文件权限
+	dirPerm os.FileMode // 自动创建的目录权限
+	sync    SyncPolicy
 
-	// 尝试加载已有数据
-	if err := s.Load(); err != nil {
-		// 文件不存在是正常现象，忽略错误
-		if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("加载数据失败: %w", err)
-		}
-	}
+	onExpire    []ExpireHandler
+	janitorStop chan struct{}
+	janitorDone chan struct{}
+
+	cache   *storageCache
+	loadMu  sync.Mutex
+	loading map[string]*loadCall
+
+	// sortedKeys 按字典序排列的全部键，供 Scan 使用
+	sortedKeys []string
+	indexes    map[string]*storageIndex
 
-	return s, nil
+	bucketTTLs map[string]time.Duration // 各 Bucket 的默认 TTL
+}
+
+// NewStorage 创建新的存储实例，数据保存在用户配置目录（Windows 为 %APPDATA%）下的 appName 目录中
+func NewStorage(appName string) (*Storage, error) {
+	return OpenStorage(StorageOptions{AppName: appName})
 }
 
 // NewStorageWithPath 使用指定路径创建存储实例
 func NewStorageWithPath(filePath string) (*Storage, error) {
-	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return nil, fmt.Errorf("无法创建目录: %w", err)
-	}
-
-	s := &Storage{
-		data:     make(map[string]*StorageItem),
-		filePath: filePath,
-		dirty:    false,
-	}
+	return OpenStorage(StorageOptions{Path: filePath})
+}
 
-	if err := s.Load(); err != nil {
-		if !os.IsNotExist(err) {
-			return nil, fmt.Errorf("加载数据失败: %w", err)
-		}
+// Set 设置键值对（无TTL），键不能以 "\x00" 开头（保留给 Bucket）
+func (s *Storage) Set(key string, value interface{}) error {
+	if err := checkKey(key); err != nil {
+		return err
 	}
-
-	return s, nil
+	return s.set(key, value)
 }
 
-// Set 设置键值对（无TTL）
-func (s *Storage) Set(key string, value interface{}) error {
+// set 设置键值对，不检查键
+func (s *Storage) set(key string, value interface{}) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.data[key] = &StorageItem{
+	s.setLocked(key, &StorageItem{
 		Value:  value,
 		HasTTL: false,
+	})
+
+	return s.saveLocked()
+}
+
+// setMany 一次写入多个键值对（无TTL），只保存一次文件
+func (s *Storage) setMany(values map[string]interface{}) error {
+	for key := range values {
+		if err := checkKey(key); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, value := range values {
+		s.setLocked(key, &StorageItem{
+			Value:  value,
+			HasTTL: false,
+		})
 	}
-	s.dirty = true
 
 	return s.saveLocked()
 }
 
 // SetWithTTL 设置带TTL的键值对
 func (s *Storage) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	return s.setWithTTL(key, value, ttl)
+}
+
+// setWithTTL 设置带TTL的键值对，不检查键
+func (s *Storage) setWithTTL(key string, value interface{}, ttl time.Duration) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.data[key] = &StorageItem{
+	s.setLocked(key, &StorageItem{
 		Value:    value,
 		ExpireAt: time.Now().Add(ttl),
 		HasTTL:   true,
-	}
-	s.dirty = true
+	})
 
 	return s.saveLocked()
 }
@@ -124,13 +149,15 @@ func (s *Storage) Get(key string) (interface{}, bool) {
 	defer s.mu.RUnlock()
 
 	item, exists := s.data[key]
-	if !exists {
+	if !exists || item.IsExpired() {
+		if s.cache != nil {
+			s.cache.touch(key, nil)
+		}
 		return nil, false
 	}
 
-	// 检查是否过期
-	if item.IsExpired() {
-		return nil, false
+	if s.cache != nil {
+		s.cache.touch(key, item)
 	}
 
 	return item.Value, true
@@ -154,27 +181,10 @@ func (s *Storage) GetInt(key string) (int64, bool) {
 		return 0, false
 	}
 
+	if n, ok := storageInt64(value); ok {
+		return n, true
+	}
 	switch v := value.(type) {
-	case int:
-		return int64(v), true
-	case int8:
-		return int64(v), true
-	case int16:
-		return int64(v), true
-	case int32:
-		return int64(v), true
-	case int64:
-		return v, true
-	case uint:
-		return int64(v), true
-	case uint8:
-		return int64(v), true
-	case uint16:
-		return int64(v), true
-	case uint32:
-		return int64(v), true
-	case uint64:
-		return int64(v), true
 	case float32:
 		return int64(v), true
 	case float64:
@@ -200,8 +210,7 @@ func (s *Storage) Delete(key string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	delete(s.data, key)
-	s.dirty = true
+	s.deleteLocked(key)
 
 	return s.saveLocked()
 }
@@ -212,14 +221,14 @@ func (s *Storage) Exists(key string) bool {
 	return exists
 }
 
-// Keys 获取所有未过期的键
+// Keys 获取所有未过期的键，不包括 Bucket 中的键
 func (s *Storage) Keys() []string {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	keys := make([]string, 0, len(s.data))
 	for key, item := range s.data {
-		if !item.IsExpired() {
+		if !item.IsExpired() && !isBucketKey(key) {
 			keys = append(keys, key)
 		}
 	}
@@ -256,7 +265,7 @@ func (s *Storage) Expire(key string, ttl time.Duration) error {
 
 	item.ExpireAt = time.Now().Add(ttl)
 	item.HasTTL = true
-	s.dirty = true
+	s.setLocked(key, item)
 
 	return s.saveLocked()
 }
@@ -272,20 +281,20 @@ func (s *Storage) Persist(key string) error {
 	}
 
 	item.HasTTL = false
-	s.dirty = true
+	s.setLocked(key, item)
 
 	return s.saveLocked()
 }
 
-// Clear 清空所有数据
+// Clear 清空所有数据，Bucket 中的数据不受影响
 func (s *Storage) Clear() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.data = make(map[string]*StorageItem)
-	s.dirty = true
-
-	return s.saveLocked()
+	return s.withFileLockLocked(func() error {
+		s.deleteWhereLocked(func(key string) bool { return !isBucketKey(key) })
+		return s.writeLocked()
+	})
 }
 
 // CleanExpired 清理过期数据
@@ -293,20 +302,170 @@ func (s *Storage) CleanExpired() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	changed := false
+	if len(s.purgeExpiredLocked()) > 0 {
+		return s.saveLocked()
+	}
+
+	return nil
+}
+
+// OnExpire 注册过期回调，过期项被清理（后台清理、CleanExpired、保存或加载）时触发
+// 回调在独立的 goroutine 中执行，可以安全地调用 Storage 的方法
+func (s *Storage) OnExpire(handler ExpireHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onExpire = append(s.onExpire, handler)
+}
+
+// StartJanitor 启动后台清理协程，按 interval 周期清理过期数据
+// 重复调用会以新的间隔重启清理协程
+func (s *Storage) StartJanitor(interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	s.StopJanitor()
+
+	s.mu.Lock()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.janitorStop = stop
+	s.janitorDone = done
+	s.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.CleanExpired(); err != nil {
+					log.Printf("[Storage] janitor clean expired failed: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// StopJanitor 停止后台清理协程
+func (s *Storage) StopJanitor() {
+	s.mu.Lock()
+	stop, done := s.janitorStop, s.janitorDone
+	s.janitorStop = nil
+	s.janitorDone = nil
+	s.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+}
+
+// setLocked 写入键并记录本地修改（已加锁）
+func (s *Storage) setLocked(key string, item *StorageItem) {
+	item.AccessedAt = time.Now()
+	if _, exists := s.data[key]; !exists {
+		s.insertSortedKeyLocked(key)
+	}
+	s.data[key] = item
+	if s.changes == nil {
+		s.changes = make(map[string]*StorageItem)
+	}
+	s.changes[key] = item
+	s.dirty = true
+	s.indexSetLocked(key, item.Value)
+
+	if s.cache != nil {
+		s.cache.put(key, itemSize(key, item))
+		s.evictLocked(key)
+	}
+}
+
+// deleteLocked 删除键并记录本地修改（已加锁）
+func (s *Storage) deleteLocked(key string) {
+	if _, exists := s.data[key]; exists {
+		s.removeSortedKeyLocked(key)
+	}
+	delete(s.data, key)
+	if s.changes == nil {
+		s.changes = make(map[string]*StorageItem)
+	}
+	s.changes[key] = nil
+	s.dirty = true
+	s.indexRemoveLocked(key)
+
+	if s.cache != nil {
+		s.cache.remove(key)
+	}
+}
+
+// deleteWhereLocked 删除所有满足 match 的键，调用方需持有文件锁，
+// 以免遗漏其他进程写入、尚未合并的键（已加锁）
+func (s *Storage) deleteWhereLocked(match func(key string) bool) {
+	var keys []string
+	for key := range s.data {
+		if match(key) {
+			keys = append(keys, key)
+		}
+	}
+	for _, key := range keys {
+		s.deleteLocked(key)
+	}
+}
+
+// replaceAllLocked 用 data 整体替换现有数据，保存时覆盖磁盘上的全部内容（已加锁）
+func (s *Storage) replaceAllLocked(data map[string]*StorageItem) {
+	s.data = data
+	s.changes = make(map[string]*StorageItem, len(data))
+	for key, item := range data {
+		s.changes[key] = item
+	}
+	s.cleared = true
+	s.dirty = true
+	s.rebuildDerivedLocked(nil)
+	s.evictLocked("")
+}
+
+// rebuildDerivedLocked 在整体替换数据后重建有序索引、二级索引和缓存记录（已加锁）
+func (s *Storage) rebuildDerivedLocked(savedIndexes map[string]*persistedIndex) {
+	s.rebuildSortedKeysLocked()
+	s.rebuildIndexesLocked(savedIndexes)
+	s.rebuildCacheLocked()
+}
+
+// purgeExpiredLocked 删除所有过期项并派发过期回调（已加锁）
+func (s *Storage) purgeExpiredLocked() []expiredEntry {
+	var expired []expiredEntry
 	for key, item := range s.data {
 		if item.IsExpired() {
+			expired = append(expired, expiredEntry{key: key, value: item.Value})
 			delete(s.data, key)
-			changed = true
+			s.indexRemoveLocked(key)
+			if s.cache != nil {
+				s.cache.remove(key)
+			}
 		}
 	}
 
-	if changed {
+	if len(expired) > 0 {
 		s.dirty = true
-		return s.saveLocked()
+		s.pruneSortedKeysLocked()
+		if len(s.onExpire) > 0 {
+			handlers := append([]ExpireHandler(nil), s.onExpire...)
+			go func() {
+				for _, entry := range expired {
+					for _, handler := range handlers {
+						handler(entry.key, entry.value)
+					}
+				}
+			}()
+		}
 	}
 
-	return nil
+	return expired
 }
 
 // Save 保存数据到文件
@@ -318,68 +477,189 @@ func (s *Storage) Save() error {
 }
 
 // saveLocked 内部保存方法（已加锁）
+// 保存时持有跨进程文件锁，并先合并其他进程写入的数据，再应用本地修改
 func (s *Storage) saveLocked() error {
 	if !s.dirty {
 		return nil
 	}
 
-	// 清理过期数据
-	for key, item := range s.data {
-		if item.IsExpired() {
-			delete(s.data, key)
-		}
+	return s.withFileLockLocked(s.writeLocked)
+}
+
+// withFileLockLocked 持有跨进程文件锁并合并磁盘上的最新数据后执行 fn（已加锁）
+func (s *Storage) withFileLockLocked(fn func() error) error {
+	unlock, err := lockStorageFile(s.filePath)
+	if err != nil {
+		return err
 	}
+	defer unlock()
 
-	var buf bytes.Buffer
-	encoder := gob.NewEncoder(&buf)
+	if err := s.mergeFromDiskLocked(); err != nil {
+		return err
+	}
 
-	if err := encoder.Encode(s.data); err != nil {
-		return fmt.Errorf("编码数据失败: %w", err)
+	return fn()
+}
+
+// writeLocked 将数据写入文件，调用方需持有文件锁（已加锁）
+func (s *Storage) writeLocked() error {
+	if !s.dirty {
+		return nil
+	}
+
+	// 清理过期数据
+	s.purgeExpiredLocked()
+
+	token := newStorageToken()
+	raw, err := encodeStorageFile(&storageFile{
+		Data:    s.data,
+		Indexes: s.persistIndexesLocked(),
+		schema:  s.schema,
+		token:   token,
+	})
+	if err != nil {
+		return err
 	}
 
 	// 写入临时文件，然后重命名，保证原子性
 	tempFile := s.filePath + ".tmp"
-	if err := os.WriteFile(tempFile, buf.Bytes(), 0644); err != nil {
+	if err := writeStorageFile(tempFile, raw, s.perm, s.sync); err != nil {
 		return fmt.Errorf("写入临时文件失败: %w", err)
 	}
 
+	s.rotateBackupsLocked()
+
 	if err := os.Rename(tempFile, s.filePath); err != nil {
 		os.Remove(tempFile)
 		return fmt.Errorf("重命名文件失败: %w", err)
 	}
 
+	s.fileToken = token
+	s.changes = nil
+	s.cleared = false
 	s.dirty = false
 	return nil
 }
 
-// Load 从文件加载数据
+// mergeFromDiskLocked 若文件自上次读写后被其他进程修改，重新读取并应用本地修改（已加锁）
+func (s *Storage) mergeFromDiskLocked() error {
+	token, err := readStorageToken(s.filePath)
+	missing := os.IsNotExist(err)
+	if err != nil && !missing {
+		return err
+	}
+	if !missing && token != 0 && token == s.fileToken {
+		return nil
+	}
+
+	var merged map[string]*StorageItem
+	var savedIndexes map[string]*persistedIndex
+	fileSchema := 0
+	if s.cleared {
+		merged = make(map[string]*StorageItem)
+	} else {
+		// 主文件缺失时 readFileLocked 会回退到备份；没有任何可用文件时以内存中的数据为基础，
+		// 避免丢失从备份恢复、尚未写回主文件的键
+		file, err := s.readFileLocked()
+		if err != nil && !(missing && os.IsNotExist(err)) {
+			return err
+		}
+		if err != nil {
+			file = &storageFile{Data: s.data}
+		}
+		merged = make(map[string]*StorageItem, len(file.Data)+len(s.changes))
+		fileSchema = file.schema
+		for key, item := range file.Data {
+			// 磁盘上已过期的项直接丢弃，不重复触发过期回调
+			if !item.IsExpired() {
+				merged[key] = item
+			}
+		}
+		savedIndexes = file.Indexes
+	}
+
+	for key, item := range s.changes {
+		if item == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = item
+		}
+		// 本地修改过的前缀，文件中保存的索引已不可信
+		for name, saved := range savedIndexes {
+			if strings.HasPrefix(key, saved.Prefix) {
+				delete(savedIndexes, name)
+			}
+		}
+	}
+
+	s.data = merged
+	s.fileToken = token
+	if fileSchema > s.schema {
+		s.schema = fileSchema
+	}
+	s.rebuildDerivedLocked(savedIndexes)
+	s.evictLocked("")
+	return nil
+}
+
+// readFileLocked 读取并解码存储文件，主文件损坏或缺失时回退到最新的有效备份（已加锁）
+func (s *Storage) readFileLocked() (*storageFile, error) {
+	file, err := readStorageFile(s.filePath)
+	if err == nil {
+		return file, nil
+	}
+
+	for _, path := range s.backupPathsLocked() {
+		backup, backupErr := readStorageFile(path)
+		if backupErr != nil {
+			continue
+		}
+		log.Printf("[Storage] %s 读取失败 (%v)，已从备份 %s 恢复", s.filePath, err, path)
+		return backup, nil
+	}
+
+	return nil, err
+}
+
+// Load 从文件加载数据，未保存的本地修改将被丢弃
 func (s *Storage) Load() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	data, err := os.ReadFile(s.filePath)
+	unlock, err := lockStorageFile(s.filePath)
 	if err != nil {
 		return err
 	}
+	defer unlock()
 
-	decoder := gob.NewDecoder(bytes.NewReader(data))
-	if err := decoder.Decode(&s.data); err != nil {
-		return fmt.Errorf("解码数据失败: %w", err)
-	}
-
-	// 清理过期数据
-	for key, item := range s.data {
-		if item.IsExpired() {
-			delete(s.data, key)
-			s.dirty = true
-		}
+	file, err := s.readFileLocked()
+	if err != nil {
+		return err
 	}
 
+	s.applyFileLocked(file)
 	return nil
 }
 
-// Close 关闭存储，保存数据
+// applyFileLocked 用读取的文件替换内存中的数据（已加锁）
+func (s *Storage) applyFileLocked(file *storageFile) {
+	s.data = file.Data
+	s.fileToken = file.token
+	s.schema = file.schema
+	s.changes = nil
+	s.cleared = false
+	// 从备份恢复时，下次保存会重写主文件
+	s.dirty = file.source != s.filePath
+	s.rebuildDerivedLocked(file.Indexes)
+
+	// 清理过期数据
+	s.purgeExpiredLocked()
+	s.evictLocked("")
+}
+
+// Close 关闭存储，停止后台清理并保存数据
 func (s *Storage) Close() error {
+	s.StopJanitor()
 	return s.Save()
 }
 
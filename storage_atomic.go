@@ -0,0 +1,181 @@
+package gohl
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Incr 将键的整数值加上 delta 并返回新值，键不存在时从 0 开始
+// 读取、修改和写入在存储锁和跨进程文件锁内完成，已有的TTL保持不变；保存失败时值保持不变
+func (s *Storage) Incr(key string, delta int64) (int64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	return s.incr(key, delta, 0)
+}
+
+// incr 同 Incr，不检查键，键不存在时以 ttl 创建（ttl <= 0 表示不过期），供 Bucket 使用
+func (s *Storage) incr(key string, delta int64, ttl time.Duration) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result int64
+	err := s.withFileLockLocked(func() error {
+		item := &StorageItem{}
+		if current, exists := s.data[key]; exists && !current.IsExpired() {
+			n, ok := storageInt64(current.Value)
+			if !ok {
+				return fmt.Errorf("值不是整数: %s (%T)", key, current.Value)
+			}
+			result = n
+			item.ExpireAt = current.ExpireAt
+			item.HasTTL = current.HasTTL
+		} else if ttl > 0 {
+			item.ExpireAt = time.Now().Add(ttl)
+			item.HasTTL = true
+		}
+
+		result += delta
+		item.Value = result
+		return s.setAndWriteLocked(key, item)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// CompareAndSwap 当键的当前值等于 old 时将其替换为 new，返回是否替换成功
+// old 为 nil 表示要求键不存在；比较使用 reflect.DeepEqual，已有的TTL保持不变
+// 保存失败时不做替换，返回 false 和错误
+func (s *Storage) CompareAndSwap(key string, old, new interface{}) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	return s.compareAndSwap(key, old, new)
+}
+
+// compareAndSwap 同 CompareAndSwap，不检查键，供 Bucket 使用
+func (s *Storage) compareAndSwap(key string, old, new interface{}) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	swapped := false
+	err := s.withFileLockLocked(func() error {
+		item := &StorageItem{Value: new}
+		current, exists := s.data[key]
+		if exists && current.IsExpired() {
+			exists = false
+		}
+
+		if exists {
+			if !reflect.DeepEqual(current.Value, old) {
+				return nil
+			}
+			item.ExpireAt = current.ExpireAt
+			item.HasTTL = current.HasTTL
+		} else if old != nil {
+			return nil
+		}
+
+		if err := s.setAndWriteLocked(key, item); err != nil {
+			return err
+		}
+		swapped = true
+		return nil
+	})
+
+	return swapped, err
+}
+
+// SetNX 仅当键不存在（或已过期）时写入，返回是否写入成功，ttl <= 0 表示不过期
+// 保存失败时不写入，返回 false 和错误
+func (s *Storage) SetNX(key string, value interface{}, ttl time.Duration) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	return s.setNX(key, value, ttl)
+}
+
+// setNX 同 SetNX，不检查键，供 Bucket 使用
+func (s *Storage) setNX(key string, value interface{}, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	set := false
+	err := s.withFileLockLocked(func() error {
+		if current, exists := s.data[key]; exists && !current.IsExpired() {
+			return nil
+		}
+
+		item := &StorageItem{Value: value}
+		if ttl > 0 {
+			item.ExpireAt = time.Now().Add(ttl)
+			item.HasTTL = true
+		}
+		if err := s.setAndWriteLocked(key, item); err != nil {
+			return err
+		}
+		set = true
+		return nil
+	})
+
+	return set, err
+}
+
+// setAndWriteLocked 写入键并保存，保存失败时恢复键原来的值和修改记录，使内存与磁盘一致
+// 调用方需持有文件锁（已加锁）
+func (s *Storage) setAndWriteLocked(key string, item *StorageItem) error {
+	prev, existed := s.data[key]
+	change, changed := s.changes[key]
+	dirty := s.dirty
+
+	s.setLocked(key, item)
+	err := s.writeLocked()
+	if err == nil {
+		return nil
+	}
+
+	if existed {
+		s.setLocked(key, prev)
+	} else {
+		s.deleteLocked(key)
+	}
+	if changed {
+		s.changes[key] = change
+	} else {
+		delete(s.changes, key)
+	}
+	s.dirty = dirty
+	return err
+}
+
+// storageInt64 将整数类型的值转换为 int64，浮点数等其他类型返回 false
+func storageInt64(value interface{}) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int8:
+		return int64(v), true
+	case int16:
+		return int64(v), true
+	case int32:
+		return int64(v), true
+	case int64:
+		return v, true
+	case uint:
+		return int64(v), true
+	case uint8:
+		return int64(v), true
+	case uint16:
+		return int64(v), true
+	case uint32:
+		return int64(v), true
+	case uint64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,232 @@
+package gohl
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"time"
+)
+
+// DefaultStorageBackups 默认保留的备份代数
+const DefaultStorageBackups = 2
+
+// RepairReport Repair 的结果
+type RepairReport struct {
+	Source    string   // 用于恢复的文件，主文件完好时即为主文件
+	Corrupted []string // 校验失败或无法解码的文件（主文件及备份）
+	Recovered []string // 恢复后存在的键
+	Lost      []string // 修复前在内存中、但恢复来源中没有的键
+
+	// Quarantined 主文件和所有备份都无法读取时，损坏的主文件被移动到的位置（<file>.corrupt-<时间>），
+	// 包括 OpenStorage 打开时移动的文件；为空表示没有移动
+	Quarantined string
+}
+
+// SetBackupCount 设置保存时保留的 .bak 备份代数，0 表示不保留备份
+func (s *Storage) SetBackupCount(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.backups = n
+}
+
+// BackupPaths 获取现有备份文件的路径，最新的在前
+func (s *Storage) BackupPaths() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var paths []string
+	for _, path := range s.backupPathsLocked() {
+		if _, err := os.Stat(path); err == nil {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// Repair 检查主文件和所有备份，用最新的有效版本重建主文件
+// 与 Load 一样，未保存的本地修改会被丢弃；报告中列出恢复的键和丢失的键
+// 没有任何可用的版本时，损坏的主文件被移到一旁并以空数据重建
+func (s *Storage) Repair() (*RepairReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockStorageFile(s.filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	report := &RepairReport{Quarantined: s.quarantined}
+	var source *storageFile
+	for _, path := range append([]string{s.filePath}, s.backupPathsLocked()...) {
+		file, err := readStorageFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				report.Corrupted = append(report.Corrupted, path)
+			}
+			continue
+		}
+		if source == nil {
+			source = file
+		}
+	}
+
+	if source == nil {
+		quarantined, err := quarantineStorageFile(s.filePath)
+		if err != nil {
+			return report, err
+		}
+		if quarantined != "" {
+			s.quarantined = quarantined
+			report.Quarantined = quarantined
+		}
+		source = &storageFile{Data: make(map[string]*StorageItem), schema: CurrentSchemaVersion()}
+	}
+	report.Source = source.source
+
+	for key := range s.data {
+		if _, ok := source.Data[key]; !ok {
+			report.Lost = append(report.Lost, key)
+		}
+	}
+	for key := range source.Data {
+		report.Recovered = append(report.Recovered, key)
+	}
+	sort.Strings(report.Lost)
+	sort.Strings(report.Recovered)
+
+	if source.source == s.filePath {
+		return report, nil
+	}
+
+	if source.source == "" {
+		log.Printf("[Storage] %s 及其备份均已损坏，以空数据重建", s.filePath)
+	} else {
+		log.Printf("[Storage] 从 %s 修复 %s", source.source, s.filePath)
+	}
+	s.schema = source.schema
+	s.replaceAllLocked(source.Data)
+	s.rebuildIndexesLocked(source.Indexes)
+	s.fileToken = 0
+
+	return report, s.writeLocked()
+}
+
+// quarantine 打开时主文件和所有备份都无法读取：将主文件移到一旁并以空数据启动
+// 其他进程已经修复了文件时改为加载修复后的数据
+func (s *Storage) quarantine() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	unlock, err := lockStorageFile(s.filePath)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	file, err := s.readFileLocked()
+	if err == nil {
+		s.applyFileLocked(file)
+		return nil
+	}
+	if !errors.Is(err, ErrStorageCorrupted) {
+		return err
+	}
+
+	quarantined, err := quarantineStorageFile(s.filePath)
+	if err != nil {
+		return err
+	}
+	log.Printf("[Storage] %s 及其备份均已损坏，已移动到 %s，以空数据启动", s.filePath, quarantined)
+	s.quarantined = quarantined
+	s.schema = CurrentSchemaVersion()
+	return nil
+}
+
+// quarantineStorageFile 将损坏的文件重命名为 <path>.corrupt-<时间>，文件不存在时返回空字符串
+func quarantineStorageFile(path string) (string, error) {
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// 不覆盖之前移走的文件
+	stamp := time.Now().Format("20060102-150405")
+	dst := path + ".corrupt-" + stamp
+	for i := 1; ; i++ {
+		if _, err := os.Stat(dst); os.IsNotExist(err) {
+			break
+		}
+		dst = fmt.Sprintf("%s.corrupt-%s-%d", path, stamp, i)
+	}
+	if err := os.Rename(path, dst); err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("移动损坏的文件失败: %w", err)
+	}
+	return dst, nil
+}
+
+// backupPathsLocked 返回所有备份代的路径，最新的在前（已加锁）
+func (s *Storage) backupPathsLocked() []string {
+	paths := make([]string, 0, s.backups)
+	for i := 1; i <= s.backups; i++ {
+		paths = append(paths, fmt.Sprintf("%s.%d.bak", s.filePath, i))
+	}
+	return paths
+}
+
+// rotateBackupsLocked 滚动备份：.N-1.bak -> .N.bak ... 主文件 -> .1.bak（已加锁）
+// 只有校验通过的主文件才会成为备份，避免用损坏的文件覆盖有效备份；
+// 主文件通过硬链接（不支持时复制）成为 .1.bak，随后再被临时文件替换，任何时刻主文件都存在
+func (s *Storage) rotateBackupsLocked() {
+	if s.backups <= 0 {
+		return
+	}
+	if err := verifyStorageFile(s.filePath); err != nil {
+		return
+	}
+
+	paths := s.backupPathsLocked()
+	for i := len(paths) - 1; i > 0; i-- {
+		if _, err := os.Stat(paths[i-1]); err == nil {
+			os.Rename(paths[i-1], paths[i])
+		}
+	}
+
+	os.Remove(paths[0])
+	if err := os.Link(s.filePath, paths[0]); err == nil {
+		return
+	}
+	if err := copyStorageFile(s.filePath, paths[0], s.perm); err != nil {
+		log.Printf("[Storage] 备份 %s 失败: %v", s.filePath, err)
+	}
+}
+
+// copyStorageFile 复制文件，先写入临时文件再重命名
+func copyStorageFile(src, dst string, perm os.FileMode) error {
+	raw, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+
+	tempFile := dst + ".tmp"
+	if err := os.WriteFile(tempFile, raw, perm); err != nil {
+		return err
+	}
+	if err := os.Rename(tempFile, dst); err != nil {
+		os.Remove(tempFile)
+		return err
+	}
+	return nil
+}
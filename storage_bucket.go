@@ -0,0 +1,218 @@
+package gohl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// bucketKeyPrefix Bucket 中的键在文件中以 "\x00<name>\x00" 开头，
+// 因此 Storage 自身的键不能以 "\x00" 开头
+const bucketKeyPrefix = "\x00"
+
+// checkKey 检查 Storage 自身的键，以 "\x00" 开头的键保留给 Bucket
+func checkKey(key string) error {
+	if isBucketKey(key) {
+		return fmt.Errorf("无效的键 %q: 不能以 \\x00 开头", key)
+	}
+	return nil
+}
+
+// isBucketKey 判断是否为 Bucket 中的键
+func isBucketKey(key string) bool {
+	return strings.HasPrefix(key, bucketKeyPrefix)
+}
+
+// Bucket Storage 中的命名空间，与其他 Bucket 及 Storage 自身的键互不影响，
+// 数据保存在同一个文件中
+type Bucket struct {
+	s      *Storage
+	name   string
+	prefix string
+}
+
+// Bucket 返回名为 name 的命名空间，name 为空或包含 "\x00" 时返回错误
+func (s *Storage) Bucket(name string) (*Bucket, error) {
+	if name == "" || strings.Contains(name, bucketKeyPrefix) {
+		return nil, fmt.Errorf("无效的 Bucket 名称 %q: 不能为空或包含 \x00", name)
+	}
+	return &Bucket{s: s, name: name, prefix: bucketKeyPrefix + name + bucketKeyPrefix}, nil
+}
+
+// Buckets 返回包含未过期数据的 Bucket 名称，按名称排序
+func (s *Storage) Buckets() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var names []string
+	for i := sort.SearchStrings(s.sortedKeys, bucketKeyPrefix); i < len(s.sortedKeys); i++ {
+		key := s.sortedKeys[i]
+		if !isBucketKey(key) {
+			break
+		}
+		if s.data[key].IsExpired() {
+			continue
+		}
+		name := key[len(bucketKeyPrefix):]
+		name = name[:strings.Index(name, bucketKeyPrefix)]
+		if len(names) == 0 || names[len(names)-1] != name {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// Name 返回 Bucket 名称
+func (b *Bucket) Name() string {
+	return b.name
+}
+
+// key 返回键在 Storage 中的实际名称
+func (b *Bucket) key(key string) string {
+	return b.prefix + key
+}
+
+// SetDefaultTTL 设置 Set 使用的默认 TTL，0 表示不过期
+// 默认 TTL 不保存到文件，应用每次启动后需要重新设置
+func (b *Bucket) SetDefaultTTL(ttl time.Duration) {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+
+	if ttl <= 0 {
+		delete(b.s.bucketTTLs, b.name)
+		return
+	}
+	if b.s.bucketTTLs == nil {
+		b.s.bucketTTLs = make(map[string]time.Duration)
+	}
+	b.s.bucketTTLs[b.name] = ttl
+}
+
+// DefaultTTL 返回默认 TTL，0 表示不过期
+func (b *Bucket) DefaultTTL() time.Duration {
+	b.s.mu.RLock()
+	defer b.s.mu.RUnlock()
+
+	return b.s.bucketTTLs[b.name]
+}
+
+// Set 设置键值对，设置了默认 TTL 时使用默认 TTL
+func (b *Bucket) Set(key string, value interface{}) error {
+	if ttl := b.DefaultTTL(); ttl > 0 {
+		return b.s.setWithTTL(b.key(key), value, ttl)
+	}
+	return b.s.set(b.key(key), value)
+}
+
+// SetWithTTL 设置带TTL的键值对
+func (b *Bucket) SetWithTTL(key string, value interface{}, ttl time.Duration) error {
+	return b.s.setWithTTL(b.key(key), value, ttl)
+}
+
+// defaultTTL 返回 ttl，ttl <= 0 时返回默认 TTL
+func (b *Bucket) defaultTTL(ttl time.Duration) time.Duration {
+	if ttl > 0 {
+		return ttl
+	}
+	return b.DefaultTTL()
+}
+
+// SetNX 同 Storage.SetNX，ttl <= 0 时使用默认 TTL
+func (b *Bucket) SetNX(key string, value interface{}, ttl time.Duration) (bool, error) {
+	return b.s.setNX(b.key(key), value, b.defaultTTL(ttl))
+}
+
+// Incr 同 Storage.Incr，键不存在时从 0 开始并使用默认 TTL
+func (b *Bucket) Incr(key string, delta int64) (int64, error) {
+	return b.s.incr(b.key(key), delta, b.DefaultTTL())
+}
+
+// CompareAndSwap 同 Storage.CompareAndSwap
+func (b *Bucket) CompareAndSwap(key string, old, new interface{}) (bool, error) {
+	return b.s.compareAndSwap(b.key(key), old, new)
+}
+
+// GetOrLoad 同 Storage.GetOrLoad，ttl <= 0 时使用默认 TTL
+func (b *Bucket) GetOrLoad(key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	return b.s.getOrLoad(b.key(key), b.defaultTTL(ttl), loader)
+}
+
+// Get 获取值
+func (b *Bucket) Get(key string) (interface{}, bool) {
+	return b.s.Get(b.key(key))
+}
+
+// GetString 获取字符串值
+func (b *Bucket) GetString(key string) (string, bool) {
+	return b.s.GetString(b.key(key))
+}
+
+// GetInt 获取整数值
+func (b *Bucket) GetInt(key string) (int64, bool) {
+	return b.s.GetInt(b.key(key))
+}
+
+// GetBool 获取布尔值
+func (b *Bucket) GetBool(key string) (bool, bool) {
+	return b.s.GetBool(b.key(key))
+}
+
+// Delete 删除键
+func (b *Bucket) Delete(key string) error {
+	return b.s.Delete(b.key(key))
+}
+
+// Exists 检查键是否存在（未过期）
+func (b *Bucket) Exists(key string) bool {
+	return b.s.Exists(b.key(key))
+}
+
+// Keys 获取所有未过期的键，按字典序排列
+func (b *Bucket) Keys() []string {
+	kvs := b.Scan("", "", 0)
+	keys := make([]string, len(kvs))
+	for i, kv := range kvs {
+		keys[i] = kv.Key
+	}
+	return keys
+}
+
+// Scan 同 Storage.Scan，返回的键不包含 Bucket 前缀
+func (b *Bucket) Scan(prefix, startAfter string, limit int) []KeyValue {
+	if startAfter != "" {
+		startAfter = b.key(startAfter)
+	}
+	kvs := b.s.Scan(b.key(prefix), startAfter, limit)
+	for i := range kvs {
+		kvs[i].Key = kvs[i].Key[len(b.prefix):]
+	}
+	return kvs
+}
+
+// TTL 获取键的剩余生存时间
+func (b *Bucket) TTL(key string) (time.Duration, bool) {
+	return b.s.TTL(b.key(key))
+}
+
+// Expire 为键设置过期时间
+func (b *Bucket) Expire(key string, ttl time.Duration) error {
+	return b.s.Expire(b.key(key), ttl)
+}
+
+// Persist 移除键的过期时间
+func (b *Bucket) Persist(key string) error {
+	return b.s.Persist(b.key(key))
+}
+
+// Clear 清空 Bucket 中的所有数据
+func (b *Bucket) Clear() error {
+	b.s.mu.Lock()
+	defer b.s.mu.Unlock()
+
+	return b.s.withFileLockLocked(func() error {
+		b.s.deleteWhereLocked(func(key string) bool { return strings.HasPrefix(key, b.prefix) })
+		return b.s.writeLocked()
+	})
+}
@@ -0,0 +1,261 @@
+package gohl
+
+import (
+	"bytes"
+	"container/list"
+	"encoding/gob"
+	"sort"
+	"sync"
+	"time"
+)
+
+// CacheOptions 缓存模式配置，超出任一限制时按 LRU（最近最少使用）淘汰
+type CacheOptions struct {
+	MaxEntries int   // 最大条目数，0 表示不限制
+	MaxBytes   int64 // 最大字节数（按 gob 编码后的大小估算），0 表示不限制
+}
+
+// CacheStats 缓存统计
+type CacheStats struct {
+	Hits      uint64 // Get 命中次数
+	Misses    uint64 // Get 未命中次数
+	Evictions uint64 // 因超出限制被淘汰的条目数
+	Entries   int    // 当前条目数
+	Bytes     int64  // 当前估算字节数
+}
+
+// cacheEntry LRU 链表节点
+type cacheEntry struct {
+	key  string
+	size int64
+}
+
+// storageCache 记录访问顺序和大小，Get 只持有 Storage 的读锁，因此使用独立的互斥锁
+type storageCache struct {
+	mu      sync.Mutex
+	opts    CacheOptions
+	lru     *list.List
+	entries map[string]*list.Element
+	bytes   int64
+	stats   CacheStats
+}
+
+// loadCall GetOrLoad 中正在进行的加载
+type loadCall struct {
+	wg  sync.WaitGroup
+	val interface{}
+	err error
+}
+
+func newStorageCache(opts CacheOptions) *storageCache {
+	return &storageCache{
+		opts:    opts,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// itemSize 估算存储项占用的字节数
+func itemSize(key string, item *StorageItem) int64 {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(item); err != nil {
+		return int64(len(key))
+	}
+	return int64(len(key) + buf.Len())
+}
+
+// put 记录写入，并将键移到最近使用的位置
+func (c *storageCache) put(key string, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*cacheEntry)
+		c.bytes += size - entry.size
+		entry.size = size
+		c.lru.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{key: key, size: size})
+	c.bytes += size
+}
+
+// remove 移除键的记录
+func (c *storageCache) remove(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[key]; ok {
+		c.bytes -= elem.Value.(*cacheEntry).size
+		c.lru.Remove(elem)
+		delete(c.entries, key)
+	}
+}
+
+// touch 记录一次 Get，命中时更新访问时间并将键移到最近使用的位置，item 为 nil 表示未命中
+// 调用方只持有 Storage 的读锁，访问时间的写入由 c.mu 保护
+func (c *storageCache) touch(key string, item *StorageItem) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if item == nil {
+		c.stats.Misses++
+		return
+	}
+	c.stats.Hits++
+	item.AccessedAt = time.Now()
+	if elem, ok := c.entries[key]; ok {
+		c.lru.MoveToFront(elem)
+	}
+}
+
+// victims 返回为满足限制需要淘汰的键（最久未使用的在前），keep 不会被淘汰
+func (c *storageCache) victims(keep string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var keys []string
+	entries, total := len(c.entries), c.bytes
+	for elem := c.lru.Back(); elem != nil; elem = elem.Prev() {
+		overEntries := c.opts.MaxEntries > 0 && entries > c.opts.MaxEntries
+		overBytes := c.opts.MaxBytes > 0 && total > c.opts.MaxBytes
+		if !overEntries && !overBytes {
+			break
+		}
+		entry := elem.Value.(*cacheEntry)
+		if entry.key == keep {
+			continue
+		}
+		keys = append(keys, entry.key)
+		entries--
+		total -= entry.size
+	}
+	c.stats.Evictions += uint64(len(keys))
+	return keys
+}
+
+// SetCacheOptions 启用缓存模式（有界存储），MaxEntries 和 MaxBytes 均为 0 时关闭
+// 启用时会按上次访问时间重建 LRU 顺序，并立即淘汰超出限制的条目
+func (s *Storage) SetCacheOptions(opts CacheOptions) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if opts.MaxEntries <= 0 && opts.MaxBytes <= 0 {
+		s.cache = nil
+		return nil
+	}
+
+	s.cache = newStorageCache(opts)
+	s.rebuildCacheLocked()
+	s.evictLocked("")
+
+	return s.saveLocked()
+}
+
+// CacheStats 获取缓存统计，未启用缓存模式时返回零值
+func (s *Storage) CacheStats() CacheStats {
+	s.mu.RLock()
+	cache := s.cache
+	s.mu.RUnlock()
+
+	if cache == nil {
+		return CacheStats{}
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	stats := cache.stats
+	stats.Entries = len(cache.entries)
+	stats.Bytes = cache.bytes
+	return stats
+}
+
+// GetOrLoad 获取值，不存在时调用 loader 加载并以 ttl 写入（ttl <= 0 表示不过期）
+// 同一个键的并发未命中只会触发一次 loader，其余调用等待并共享其结果
+func (s *Storage) GetOrLoad(key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return s.getOrLoad(key, ttl, loader)
+}
+
+// getOrLoad 同 GetOrLoad，不检查键，供 Bucket 使用
+func (s *Storage) getOrLoad(key string, ttl time.Duration, loader func() (interface{}, error)) (interface{}, error) {
+	if value, exists := s.Get(key); exists {
+		return value, nil
+	}
+
+	s.loadMu.Lock()
+	if call, ok := s.loading[key]; ok {
+		s.loadMu.Unlock()
+		call.wg.Wait()
+		return call.val, call.err
+	}
+	// 其他调用可能在第一次检查之后完成了加载并已移除 loading 记录
+	if value, exists := s.Get(key); exists {
+		s.loadMu.Unlock()
+		return value, nil
+	}
+	call := &loadCall{}
+	call.wg.Add(1)
+	if s.loading == nil {
+		s.loading = make(map[string]*loadCall)
+	}
+	s.loading[key] = call
+	s.loadMu.Unlock()
+
+	defer func() {
+		s.loadMu.Lock()
+		delete(s.loading, key)
+		s.loadMu.Unlock()
+		call.wg.Done()
+	}()
+
+	call.val, call.err = loader()
+	if call.err != nil {
+		return nil, call.err
+	}
+
+	if ttl > 0 {
+		call.err = s.setWithTTL(key, call.val, ttl)
+	} else {
+		call.err = s.set(key, call.val)
+	}
+
+	return call.val, call.err
+}
+
+// rebuildCacheLocked 按上次访问时间重建 LRU 记录（已加锁）
+func (s *Storage) rebuildCacheLocked() {
+	if s.cache == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return s.data[keys[i]].AccessedAt.Before(s.data[keys[j]].AccessedAt)
+	})
+
+	cache := newStorageCache(s.cache.opts)
+	cache.stats = s.cache.stats
+	for _, key := range keys {
+		cache.put(key, itemSize(key, s.data[key]))
+	}
+	s.cache = cache
+}
+
+// evictLocked 淘汰超出限制的条目，keep 为刚写入的键，不会被淘汰（已加锁）
+func (s *Storage) evictLocked(keep string) {
+	if s.cache == nil {
+		return
+	}
+
+	for _, key := range s.cache.victims(keep) {
+		s.deleteLocked(key)
+	}
+}
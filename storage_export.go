@@ -0,0 +1,362 @@
+package gohl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ExportFormat 导出格式
+type ExportFormat int
+
+const (
+	ExportJSON      ExportFormat = iota // 单个 JSON 文档：{"schema":N,"entries":[...]}
+	ExportJSONLines                     // 每行一个 JSON 条目
+)
+
+// ImportMode 导入模式
+type ImportMode int
+
+const (
+	ImportMerge        ImportMode = iota // 覆盖同名键，保留其他键
+	ImportSkipExisting                   // 已存在（未过期）的键保持不变
+	ImportReplace                        // 清空现有数据后导入
+)
+
+// 导出条目的值类型
+const (
+	exportTypeString  = "string"
+	exportTypeBool    = "bool"
+	exportTypeInt     = "int"
+	exportTypeInt8    = "int8"
+	exportTypeInt16   = "int16"
+	exportTypeInt32   = "int32"
+	exportTypeInt64   = "int64"
+	exportTypeUint    = "uint"
+	exportTypeUint8   = "uint8"
+	exportTypeUint16  = "uint16"
+	exportTypeUint32  = "uint32"
+	exportTypeUint64  = "uint64"
+	exportTypeFloat32 = "float32"
+	exportTypeFloat64 = "float64"
+	exportTypeBytes   = "bytes"
+	exportTypeTyped   = "typed:" // SetTyped 写入的值，后接类型名，value 为 gob 数据的 base64
+	exportTypeGob     = "gob"    // 其他通过 gob.Register 注册的类型（包括 UndecodedValue），value 为 gob 数据的 base64
+)
+
+// exportEntry 导出的单个键
+type exportEntry struct {
+	Key      string          `json:"key"`
+	Type     string          `json:"type"`
+	Value    json.RawMessage `json:"value"`
+	ExpireAt *time.Time      `json:"expire_at,omitempty"`
+}
+
+// exportDocument ExportJSON 格式的文档
+type exportDocument struct {
+	Schema  int           `json:"schema"`
+	Entries []exportEntry `json:"entries"`
+}
+
+// Export 按键排序导出所有未过期的键，保留值的类型和过期时间
+func (s *Storage) Export(w io.Writer, format ExportFormat) error {
+	s.mu.RLock()
+	entries := make([]exportEntry, 0, len(s.sortedKeys))
+	for _, key := range s.sortedKeys {
+		item := s.data[key]
+		if item.IsExpired() {
+			continue
+		}
+		entry, err := newExportEntry(key, item)
+		if err != nil {
+			s.mu.RUnlock()
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	schema := s.schema
+	s.mu.RUnlock()
+
+	switch format {
+	case ExportJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(exportDocument{Schema: schema, Entries: entries})
+	case ExportJSONLines:
+		enc := json.NewEncoder(w)
+		for _, entry := range entries {
+			if err := enc.Encode(entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown export format: %d", format)
+	}
+}
+
+// ExportKey 以 ExportJSONLines 的条目格式导出单个未过期的键
+func (s *Storage) ExportKey(w io.Writer, key string) error {
+	s.mu.RLock()
+	item, exists := s.data[key]
+	if !exists || item.IsExpired() {
+		s.mu.RUnlock()
+		return fmt.Errorf("key not found: %s", key)
+	}
+	entry, err := newExportEntry(key, item)
+	s.mu.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	return json.NewEncoder(w).Encode(entry)
+}
+
+// Import 导入 Export 生成的数据（自动识别 ExportJSON 和 ExportJSONLines），返回导入的键数
+// 已过期的条目会被跳过
+func (s *Storage) Import(r io.Reader, mode ImportMode) (int, error) {
+	entries, err := readExportEntries(r)
+	if err != nil {
+		return 0, err
+	}
+
+	items := make(map[string]*StorageItem, len(entries))
+	for _, entry := range entries {
+		item, err := entry.item()
+		if err != nil {
+			return 0, err
+		}
+		if !item.IsExpired() {
+			items[entry.Key] = item
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	imported := 0
+	err = s.withFileLockLocked(func() error {
+		if mode == ImportReplace {
+			s.replaceAllLocked(items)
+			imported = len(items)
+			return s.writeLocked()
+		}
+
+		for key, item := range items {
+			if mode == ImportSkipExisting {
+				if current, exists := s.data[key]; exists && !current.IsExpired() {
+					continue
+				}
+			}
+			s.setLocked(key, item)
+			imported++
+		}
+		return s.writeLocked()
+	})
+
+	return imported, err
+}
+
+// Compact 清理过期数据并重写文件
+func (s *Storage) Compact() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.withFileLockLocked(func() error {
+		s.dirty = true
+		return s.writeLocked()
+	})
+}
+
+// readExportEntries 读取 ExportJSON 文档或 ExportJSONLines 条目
+func readExportEntries(r io.Reader) ([]exportEntry, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var entries []exportEntry
+	for {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err == io.EOF {
+			return entries, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("解析导入数据失败: %w", err)
+		}
+
+		var doc exportDocument
+		if err := json.Unmarshal(raw, &doc); err == nil && doc.Entries != nil {
+			entries = append(entries, doc.Entries...)
+			continue
+		}
+
+		var entry exportEntry
+		if err := json.Unmarshal(raw, &entry); err != nil {
+			return nil, fmt.Errorf("解析导入数据失败: %w", err)
+		}
+		if entry.Key == "" {
+			return nil, fmt.Errorf("解析导入数据失败: 条目缺少 key")
+		}
+		entries = append(entries, entry)
+	}
+}
+
+// newExportEntry 将存储项转换为导出条目
+func newExportEntry(key string, item *StorageItem) (exportEntry, error) {
+	entry := exportEntry{Key: key}
+	if item.HasTTL {
+		expireAt := item.ExpireAt
+		entry.ExpireAt = &expireAt
+	}
+
+	var value interface{}
+	switch v := item.Value.(type) {
+	case string:
+		entry.Type, value = exportTypeString, v
+	case bool:
+		entry.Type, value = exportTypeBool, v
+	case int:
+		entry.Type, value = exportTypeInt, v
+	case int8:
+		entry.Type, value = exportTypeInt8, v
+	case int16:
+		entry.Type, value = exportTypeInt16, v
+	case int32:
+		entry.Type, value = exportTypeInt32, v
+	case int64:
+		// 以字符串保存，避免超出 JSON 数字精度
+		entry.Type, value = exportTypeInt64, strconv.FormatInt(v, 10)
+	case uint:
+		entry.Type, value = exportTypeUint, v
+	case uint8:
+		entry.Type, value = exportTypeUint8, v
+	case uint16:
+		entry.Type, value = exportTypeUint16, v
+	case uint32:
+		entry.Type, value = exportTypeUint32, v
+	case uint64:
+		entry.Type, value = exportTypeUint64, strconv.FormatUint(v, 10)
+	case float32:
+		entry.Type, value = exportTypeFloat32, v
+	case float64:
+		entry.Type, value = exportTypeFloat64, v
+	case []byte:
+		entry.Type, value = exportTypeBytes, v
+	case typedValue:
+		entry.Type, value = exportTypeTyped+v.Type, v.Data
+	case UndecodedValue:
+		entry.Type, value = exportTypeGob, v.Data
+	default:
+		var buf bytes.Buffer
+		if err := gob.NewEncoder(&buf).Encode(&item.Value); err != nil {
+			return entry, fmt.Errorf("导出 %s 失败: %w", key, err)
+		}
+		entry.Type, value = exportTypeGob, buf.Bytes()
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return entry, fmt.Errorf("导出 %s 失败: %w", key, err)
+	}
+	entry.Value = raw
+	return entry, nil
+}
+
+// item 将导出条目还原为存储项
+func (e exportEntry) item() (*StorageItem, error) {
+	item := &StorageItem{}
+	if e.ExpireAt != nil {
+		item.ExpireAt = *e.ExpireAt
+		item.HasTTL = true
+	}
+
+	var err error
+	switch {
+	case e.Type == exportTypeString:
+		var v string
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeBool:
+		var v bool
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeInt:
+		var v int
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeInt8:
+		var v int8
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeInt16:
+		var v int16
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeInt32:
+		var v int32
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeInt64:
+		var v string
+		if err = json.Unmarshal(e.Value, &v); err == nil {
+			item.Value, err = strconv.ParseInt(v, 10, 64)
+		}
+	case e.Type == exportTypeUint:
+		var v uint
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeUint8:
+		var v uint8
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeUint16:
+		var v uint16
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeUint32:
+		var v uint32
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeUint64:
+		var v string
+		if err = json.Unmarshal(e.Value, &v); err == nil {
+			item.Value, err = strconv.ParseUint(v, 10, 64)
+		}
+	case e.Type == exportTypeFloat32:
+		var v float32
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeFloat64:
+		var v float64
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case e.Type == exportTypeBytes:
+		var v []byte
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = v
+	case strings.HasPrefix(e.Type, exportTypeTyped):
+		var v []byte
+		err = json.Unmarshal(e.Value, &v)
+		item.Value = typedValue{Type: strings.TrimPrefix(e.Type, exportTypeTyped), Data: v}
+	case e.Type == exportTypeGob:
+		var encoded string
+		if err = json.Unmarshal(e.Value, &encoded); err == nil {
+			var data []byte
+			if data, err = base64.StdEncoding.DecodeString(encoded); err == nil {
+				// 类型没有在当前程序中注册时原样保留
+				item.Value = decodeStorageValue(e.Key, data)
+			}
+		}
+	default:
+		err = fmt.Errorf("unknown value type: %s", e.Type)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("导入 %s 失败: %w", e.Key, err)
+	}
+	return item, nil
+}
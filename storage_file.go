@@ -0,0 +1,221 @@
+package gohl
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// storageMagic 存储文件头部标识，旧版本的文件直接以 gob 数据开头
+const storageMagic = "GOHL"
+
+// storageHeaderSize 文件头大小：标识 + 4 字节 schema 版本 + 8 字节写入标记 + 4 字节数据校验和（CRC32）
+const storageHeaderSize = len(storageMagic) + 4 + 8 + 4
+
+// ErrStorageCorrupted 存储文件校验失败或无法解码
+var ErrStorageCorrupted = errors.New("存储文件已损坏")
+
+// storageFile 存储文件的内容
+type storageFile struct {
+	Data    map[string]*StorageItem
+	Indexes map[string]*persistedIndex
+
+	schema int    // schema 版本，保存在文件头中
+	token  uint64 // 写入标记，每次保存随机生成，保存在文件头中
+	source string // 读取时的来源文件
+}
+
+// fileContent 文件头之后的 gob 数据
+// 每个值单独编码保存在 Items 中，某个值的类型无法解码时不影响其他键；Data 是旧格式整体编码的数据，只在读取时使用
+type fileContent struct {
+	Data    map[string]*StorageItem
+	Items   map[string]*fileItem
+	Indexes map[string]*persistedIndex
+}
+
+// fileItem 文件中的存储项，Value 为 gob 编码的值
+type fileItem struct {
+	Value      []byte
+	ExpireAt   time.Time
+	HasTTL     bool
+	AccessedAt time.Time
+}
+
+// UndecodedValue 存储文件中无法解码的值，通常是类型没有在当前程序中用 gob.Register 注册
+// 读取时不会导致加载失败，原始数据会保留并在保存时原样写回
+type UndecodedValue struct {
+	Data []byte // gob 编码的值，与导出格式 "gob" 的数据相同
+	Err  error  // 解码失败的原因
+}
+
+// newStorageToken 生成新的写入标记
+func newStorageToken() uint64 {
+	var b [8]byte
+	rand.Read(b[:])
+	return binary.LittleEndian.Uint64(b[:]) | 1
+}
+
+// encodeStorageFile 编码存储文件
+func encodeStorageFile(file *storageFile) ([]byte, error) {
+	content := fileContent{
+		Items:   make(map[string]*fileItem, len(file.Data)),
+		Indexes: file.Indexes,
+	}
+	for key, item := range file.Data {
+		value, err := encodeStorageValue(item.Value)
+		if err != nil {
+			return nil, fmt.Errorf("编码 %s 失败: %w", key, err)
+		}
+		content.Items[key] = &fileItem{
+			Value:      value,
+			ExpireAt:   item.ExpireAt,
+			HasTTL:     item.HasTTL,
+			AccessedAt: item.AccessedAt,
+		}
+	}
+
+	var payload bytes.Buffer
+	if err := gob.NewEncoder(&payload).Encode(&content); err != nil {
+		return nil, fmt.Errorf("编码数据失败: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(storageHeaderSize + payload.Len())
+	buf.WriteString(storageMagic)
+	binary.Write(&buf, binary.LittleEndian, uint32(file.schema))
+	binary.Write(&buf, binary.LittleEndian, file.token)
+	binary.Write(&buf, binary.LittleEndian, crc32.ChecksumIEEE(payload.Bytes()))
+	buf.Write(payload.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// decodeStorageFile 解码存储文件，兼容没有文件头、只保存了数据 map 的旧格式（schema 版本视为 0）
+func decodeStorageFile(raw []byte) (*storageFile, error) {
+	if !bytes.HasPrefix(raw, []byte(storageMagic)) || len(raw) < storageHeaderSize {
+		data := make(map[string]*StorageItem)
+		if err := gob.NewDecoder(bytes.NewReader(raw)).Decode(&data); err != nil {
+			return nil, storageDecodeError(err)
+		}
+		return &storageFile{Data: data}, nil
+	}
+
+	payload := raw[storageHeaderSize:]
+	checksum := binary.LittleEndian.Uint32(raw[len(storageMagic)+4+8:])
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, fmt.Errorf("%w: 校验和不匹配", ErrStorageCorrupted)
+	}
+
+	var content fileContent
+	if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&content); err != nil {
+		return nil, storageDecodeError(err)
+	}
+
+	file := &storageFile{
+		Data:    content.Data,
+		Indexes: content.Indexes,
+		schema:  int(binary.LittleEndian.Uint32(raw[len(storageMagic):])),
+		token:   binary.LittleEndian.Uint64(raw[len(storageMagic)+4:]),
+	}
+	if file.Data == nil {
+		file.Data = make(map[string]*StorageItem, len(content.Items))
+	}
+	for key, item := range content.Items {
+		file.Data[key] = &StorageItem{
+			Value:      decodeStorageValue(key, item.Value),
+			ExpireAt:   item.ExpireAt,
+			HasTTL:     item.HasTTL,
+			AccessedAt: item.AccessedAt,
+		}
+	}
+	return file, nil
+}
+
+// storageDecodeError 包装解码错误
+// 旧格式的数据整体编码，值的类型没有注册时整个文件都无法解码，但文件本身并未损坏，不视为 ErrStorageCorrupted
+func storageDecodeError(err error) error {
+	if strings.Contains(err.Error(), "name not registered for interface") {
+		return fmt.Errorf("解码数据失败（值的类型没有通过 gob.Register 注册）: %w", err)
+	}
+	return fmt.Errorf("%w: 解码数据失败: %v", ErrStorageCorrupted, err)
+}
+
+// encodeStorageValue 单独编码一个值，UndecodedValue 原样写回
+func encodeStorageValue(value interface{}) ([]byte, error) {
+	if v, ok := value.(UndecodedValue); ok {
+		return v.Data, nil
+	}
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeStorageValue 解码一个值，失败时返回保留原始数据的 UndecodedValue
+func decodeStorageValue(key string, data []byte) interface{} {
+	var value interface{}
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&value); err != nil {
+		log.Printf("[Storage] 无法解码 %s: %v", key, err)
+		return UndecodedValue{Data: data, Err: err}
+	}
+	return value
+}
+
+// readStorageFile 读取并解码指定路径的存储文件
+func readStorageFile(path string) (*storageFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := decodeStorageFile(raw)
+	if err != nil {
+		return nil, err
+	}
+	file.source = path
+	return file, nil
+}
+
+// verifyStorageFile 只校验文件头和校验和，不解码数据；旧格式的文件只能在解码时发现损坏
+func verifyStorageFile(path string) error {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !bytes.HasPrefix(raw, []byte(storageMagic)) || len(raw) < storageHeaderSize {
+		return nil
+	}
+	checksum := binary.LittleEndian.Uint32(raw[len(storageMagic)+4+8:])
+	if crc32.ChecksumIEEE(raw[storageHeaderSize:]) != checksum {
+		return fmt.Errorf("%w: 校验和不匹配", ErrStorageCorrupted)
+	}
+	return nil
+}
+
+// readStorageToken 只读取文件头中的写入标记，旧格式的文件返回 0
+func readStorageToken(path string) (uint64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	header := make([]byte, storageHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return 0, nil
+	}
+	if string(header[:len(storageMagic)]) != storageMagic {
+		return 0, nil
+	}
+	return binary.LittleEndian.Uint64(header[len(storageMagic)+4 : len(storageMagic)+4+8]), nil
+}
@@ -0,0 +1,234 @@
+package gohl
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IndexExtractor 从值中提取索引词，返回 nil 表示该值不进入索引
+type IndexExtractor func(value interface{}) []string
+
+// storageIndex 二级索引，维护 索引词 -> 键集合
+type storageIndex struct {
+	prefix   string
+	extract  IndexExtractor // 为 nil 表示从文件加载、尚未通过 CreateIndex 注册
+	terms    map[string]map[string]struct{}
+	keyTerms map[string][]string
+}
+
+// persistedIndex 随数据一起保存的索引内容
+type persistedIndex struct {
+	Prefix string
+	Terms  map[string][]string
+}
+
+func newStorageIndex(prefix string, extract IndexExtractor) *storageIndex {
+	return &storageIndex{
+		prefix:   prefix,
+		extract:  extract,
+		terms:    make(map[string]map[string]struct{}),
+		keyTerms: make(map[string][]string),
+	}
+}
+
+// add 将键按提取出的索引词加入索引，已存在的旧索引词会先被移除
+func (idx *storageIndex) add(key string, value interface{}) {
+	idx.remove(key)
+
+	terms := idx.extract(value)
+	if len(terms) == 0 {
+		return
+	}
+	for _, term := range terms {
+		keys, ok := idx.terms[term]
+		if !ok {
+			keys = make(map[string]struct{})
+			idx.terms[term] = keys
+		}
+		keys[key] = struct{}{}
+	}
+	idx.keyTerms[key] = terms
+}
+
+// remove 将键从索引中移除
+func (idx *storageIndex) remove(key string) {
+	for _, term := range idx.keyTerms[key] {
+		if keys, ok := idx.terms[term]; ok {
+			delete(keys, key)
+			if len(keys) == 0 {
+				delete(idx.terms, term)
+			}
+		}
+	}
+	delete(idx.keyTerms, key)
+}
+
+func (idx *storageIndex) persist() *persistedIndex {
+	p := &persistedIndex{Prefix: idx.prefix, Terms: make(map[string][]string, len(idx.terms))}
+	for term, keys := range idx.terms {
+		list := make([]string, 0, len(keys))
+		for key := range keys {
+			list = append(list, key)
+		}
+		sort.Strings(list)
+		p.Terms[term] = list
+	}
+	return p
+}
+
+func restoreIndex(p *persistedIndex) *storageIndex {
+	idx := newStorageIndex(p.Prefix, nil)
+	for term, keys := range p.Terms {
+		set := make(map[string]struct{}, len(keys))
+		for _, key := range keys {
+			set[key] = struct{}{}
+			idx.keyTerms[key] = append(idx.keyTerms[key], term)
+		}
+		idx.terms[term] = set
+	}
+	return idx
+}
+
+// IndexBy 将按类型 T 提取索引词的函数包装为 IndexExtractor，
+// 同时支持 Set 直接保存的 T 和 SetTyped 保存的 T，类型不符的值不进入索引
+func IndexBy[T any](extract func(value T) []string) IndexExtractor {
+	return func(value interface{}) []string {
+		v, err := ValueAs[T](value)
+		if err != nil {
+			return nil
+		}
+		return extract(v)
+	}
+}
+
+// CreateIndex 创建（或重建）名为 name 的二级索引，覆盖以 prefix 开头的键
+// 索引在每次 Set/Delete 时维护，并随数据一起保存到文件
+// 索引函数无法持久化，应用每次启动后需要重新调用 CreateIndex；
+// 在此之前 Lookup 使用文件中保存的索引，若期间修改了该前缀下的键，保存的索引将被丢弃
+func (s *Storage) CreateIndex(name, prefix string, extract IndexExtractor) error {
+	if extract == nil {
+		return fmt.Errorf("索引 %s 缺少提取函数", name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	idx := newStorageIndex(prefix, extract)
+	s.fillIndexLocked(idx)
+
+	if s.indexes == nil {
+		s.indexes = make(map[string]*storageIndex)
+	}
+	s.indexes[name] = idx
+	s.dirty = true
+
+	return s.saveLocked()
+}
+
+// DropIndex 删除二级索引
+func (s *Storage) DropIndex(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.indexes[name]; !exists {
+		return nil
+	}
+	delete(s.indexes, name)
+	s.dirty = true
+
+	return s.saveLocked()
+}
+
+// Lookup 通过二级索引查找索引词为 term 的未过期键值对，结果按键排序
+// SetTyped 保存的值可以用 ValueAs 转换回原类型
+func (s *Storage) Lookup(index, term string) ([]KeyValue, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, exists := s.indexes[index]
+	if !exists {
+		return nil, fmt.Errorf("index not found: %s", index)
+	}
+
+	keys := make([]string, 0, len(idx.terms[term]))
+	for key := range idx.terms[term] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]KeyValue, 0, len(keys))
+	for _, key := range keys {
+		item, ok := s.data[key]
+		if !ok || item.IsExpired() {
+			continue
+		}
+		result = append(result, KeyValue{Key: key, Value: item.Value})
+	}
+
+	return result, nil
+}
+
+// fillIndexLocked 按有序索引扫描前缀范围，填充二级索引（已加锁）
+func (s *Storage) fillIndexLocked(idx *storageIndex) {
+	for i := sort.SearchStrings(s.sortedKeys, idx.prefix); i < len(s.sortedKeys); i++ {
+		key := s.sortedKeys[i]
+		if !strings.HasPrefix(key, idx.prefix) {
+			break
+		}
+		idx.add(key, s.data[key].Value)
+	}
+}
+
+// indexSetLocked 写入键后更新二级索引（已加锁）
+func (s *Storage) indexSetLocked(key string, value interface{}) {
+	for name, idx := range s.indexes {
+		if !strings.HasPrefix(key, idx.prefix) {
+			continue
+		}
+		if idx.extract == nil {
+			delete(s.indexes, name)
+			continue
+		}
+		idx.add(key, value)
+	}
+}
+
+// indexRemoveLocked 删除键后更新二级索引（已加锁）
+func (s *Storage) indexRemoveLocked(key string) {
+	for _, idx := range s.indexes {
+		if strings.HasPrefix(key, idx.prefix) {
+			idx.remove(key)
+		}
+	}
+}
+
+// rebuildIndexesLocked 在整体替换数据后重建二级索引（已加锁）
+// saved 为文件中保存的索引，用于尚未通过 CreateIndex 注册的索引
+func (s *Storage) rebuildIndexesLocked(saved map[string]*persistedIndex) {
+	indexes := make(map[string]*storageIndex, len(s.indexes)+len(saved))
+	for name, p := range saved {
+		indexes[name] = restoreIndex(p)
+	}
+	for name, idx := range s.indexes {
+		if idx.extract == nil {
+			continue
+		}
+		rebuilt := newStorageIndex(idx.prefix, idx.extract)
+		s.fillIndexLocked(rebuilt)
+		indexes[name] = rebuilt
+	}
+	s.indexes = indexes
+}
+
+// persistIndexesLocked 返回需要写入文件的索引（已加锁）
+func (s *Storage) persistIndexesLocked() map[string]*persistedIndex {
+	if len(s.indexes) == 0 {
+		return nil
+	}
+	saved := make(map[string]*persistedIndex, len(s.indexes))
+	for name, idx := range s.indexes {
+		saved[name] = idx.persist()
+	}
+	return saved
+}
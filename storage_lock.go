@@ -0,0 +1,39 @@
+package gohl
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+const (
+	LOCKFILE_EXCLUSIVE_LOCK = 0x00000002
+)
+
+// lockStorageFile 获取存储文件的跨进程排他锁（advisory），返回释放函数
+// 锁加在独立的 .lock 文件上，因为数据文件在保存时会被重命名替换
+func lockStorageFile(path string) (func(), error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("打开锁文件失败: %w", err)
+	}
+
+	handle := f.Fd()
+	var ol syscall.Overlapped
+	r1, _, e1 := procLockFileEx.Call(handle, LOCKFILE_EXCLUSIVE_LOCK, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+	if r1 == 0 {
+		f.Close()
+		return nil, fmt.Errorf("锁定存储文件失败: %w", e1)
+	}
+
+	return func() {
+		procUnlockFileEx.Call(handle, 0, 1, 0, uintptr(unsafe.Pointer(&ol)))
+		f.Close()
+	}, nil
+}
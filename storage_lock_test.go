@@ -0,0 +1,87 @@
+package gohl
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+const (
+	storageChildEnv  = "GOHL_STORAGE_TEST_CHILD"
+	storagePathEnv   = "GOHL_STORAGE_TEST_PATH"
+	storageChildren  = 4
+	storageChildKeys = 50
+)
+
+// TestStorageMultiProcess 多个进程同时对同一文件写入互不相同的键，所有键都应保留
+func TestStorageMultiProcess(t *testing.T) {
+	if child := os.Getenv(storageChildEnv); child != "" {
+		runStorageChild(t, child, os.Getenv(storagePathEnv))
+		return
+	}
+
+	path := filepath.Join(t.TempDir(), "storage.dat")
+
+	cmds := make([]*exec.Cmd, storageChildren)
+	for i := range cmds {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestStorageMultiProcess$")
+		cmd.Env = append(os.Environ(), storageChildEnv+"="+strconv.Itoa(i), storagePathEnv+"="+path)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("启动子进程失败: %v", err)
+		}
+		cmds[i] = cmd
+	}
+	for i, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("子进程 %d 失败: %v", i, err)
+		}
+	}
+
+	s, err := NewStorageWithPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	for i := 0; i < storageChildren; i++ {
+		for j := 0; j < storageChildKeys; j++ {
+			key := storageChildKey(i, j)
+			if v, ok := s.GetInt(key); !ok || v != int64(j) {
+				t.Errorf("%s = %v, %v; want %d", key, v, ok, j)
+			}
+		}
+	}
+	if got, want := len(s.Keys()), storageChildren*storageChildKeys; got != want {
+		t.Errorf("len(Keys()) = %d; want %d", got, want)
+	}
+}
+
+// runStorageChild 子进程：打开同一文件并逐个写入自己的键
+func runStorageChild(t *testing.T, child, path string) {
+	i, err := strconv.Atoi(child)
+	if err != nil || path == "" {
+		t.Fatalf("无效的子进程参数: %q %q", child, path)
+	}
+
+	s, err := NewStorageWithPath(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for j := 0; j < storageChildKeys; j++ {
+		if err := s.Set(storageChildKey(i, j), j); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func storageChildKey(child, n int) string {
+	return fmt.Sprintf("child%d.key%03d", child, n)
+}
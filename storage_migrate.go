@@ -0,0 +1,181 @@
+package gohl
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MigrationFunc 数据迁移函数，返回错误时整个迁移被放弃，原文件保持不变
+type MigrationFunc func(tx *MigrationTx) error
+
+// MigrationTx 迁移事务，操作的是数据副本，全部迁移成功后才会写回文件
+type MigrationTx struct {
+	data map[string]*StorageItem
+	from int
+	to   int
+}
+
+type migration struct {
+	to int
+	fn MigrationFunc
+}
+
+var (
+	migrationsMu sync.RWMutex
+	migrations   = make(map[int]migration)
+)
+
+// RegisterMigration 注册从版本 from 升级到版本 to 的迁移，需在 NewStorage 之前调用
+// 所有迁移中最大的 to 即为当前的 schema 版本；打开旧版本的文件时按 from -> to 的链依次执行
+func RegisterMigration(from, to int, fn MigrationFunc) {
+	if to <= from {
+		panic(fmt.Sprintf("invalid storage migration: %d -> %d", from, to))
+	}
+
+	migrationsMu.Lock()
+	defer migrationsMu.Unlock()
+
+	migrations[from] = migration{to: to, fn: fn}
+}
+
+// CurrentSchemaVersion 当前的 schema 版本，即已注册迁移的最大目标版本，未注册时为 0
+func CurrentSchemaVersion() int {
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	version := 0
+	for _, m := range migrations {
+		if m.to > version {
+			version = m.to
+		}
+	}
+	return version
+}
+
+// SchemaVersion 获取存储文件的 schema 版本
+func (s *Storage) SchemaVersion() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.schema
+}
+
+// migrate 将文件升级到当前 schema 版本，在 NewStorage/NewStorageWithPath 中自动调用
+func (s *Storage) migrate() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.withFileLockLocked(s.migrateLocked)
+}
+
+// migrateLocked 执行迁移并写回文件，调用方需持有文件锁（已加锁）
+func (s *Storage) migrateLocked() error {
+	data, target, err := migrateData(s.data, s.schema)
+	if err != nil || target == s.schema {
+		return err
+	}
+
+	s.replaceAllLocked(data)
+	s.schema = target
+
+	return s.writeLocked()
+}
+
+// migrateData 在数据副本上把版本 from 的数据依次迁移到当前 schema 版本，返回迁移后的数据和版本
+// 已是当前版本时原样返回 data；失败时 data 不受影响
+func migrateData(data map[string]*StorageItem, from int) (map[string]*StorageItem, int, error) {
+	target := CurrentSchemaVersion()
+	if from >= target {
+		return data, from, nil
+	}
+
+	migrationsMu.RLock()
+	defer migrationsMu.RUnlock()
+
+	tx := &MigrationTx{data: make(map[string]*StorageItem, len(data))}
+	for key, item := range data {
+		copied := *item
+		tx.data[key] = &copied
+	}
+
+	for version := from; version < target; {
+		m, ok := migrations[version]
+		if !ok {
+			return nil, from, fmt.Errorf("缺少从版本 %d 开始的迁移", version)
+		}
+		tx.from, tx.to = version, m.to
+		if err := m.fn(tx); err != nil {
+			return nil, from, fmt.Errorf("迁移 %d -> %d 失败: %w", version, m.to, err)
+		}
+		version = m.to
+	}
+
+	return tx.data, target, nil
+}
+
+// From 本次迁移的起始版本
+func (tx *MigrationTx) From() int {
+	return tx.from
+}
+
+// To 本次迁移的目标版本
+func (tx *MigrationTx) To() int {
+	return tx.to
+}
+
+// Get 获取值
+func (tx *MigrationTx) Get(key string) (interface{}, bool) {
+	item, exists := tx.data[key]
+	if !exists || item.IsExpired() {
+		return nil, false
+	}
+	return item.Value, true
+}
+
+// Set 设置值，保留原有的TTL
+func (tx *MigrationTx) Set(key string, value interface{}) {
+	if item, exists := tx.data[key]; exists {
+		item.Value = value
+		return
+	}
+	tx.data[key] = &StorageItem{Value: value}
+}
+
+// SetWithTTL 设置带TTL的值
+func (tx *MigrationTx) SetWithTTL(key string, value interface{}, ttl time.Duration) {
+	tx.data[key] = &StorageItem{
+		Value:    value,
+		ExpireAt: time.Now().Add(ttl),
+		HasTTL:   true,
+	}
+}
+
+// Delete 删除键
+func (tx *MigrationTx) Delete(key string) {
+	delete(tx.data, key)
+}
+
+// Rename 重命名键，保留TTL，原键不存在时返回 false
+func (tx *MigrationTx) Rename(oldKey, newKey string) bool {
+	item, exists := tx.data[oldKey]
+	if !exists {
+		return false
+	}
+	delete(tx.data, oldKey)
+	tx.data[newKey] = item
+	return true
+}
+
+// Keys 获取所有未过期的键（已排序）
+func (tx *MigrationTx) Keys() []string {
+	keys := make([]string, 0, len(tx.data))
+	for key, item := range tx.data {
+		if !item.IsExpired() {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,132 @@
+package gohl
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// SyncPolicy 写入文件时的刷盘策略
+type SyncPolicy int
+
+const (
+	SyncNone   SyncPolicy = iota // 不主动刷盘，由操作系统决定写回时机
+	SyncAlways                   // 每次保存在重命名前刷盘，断电时不会留下空文件
+)
+
+// StorageOptions OpenStorage 的配置
+type StorageOptions struct {
+	AppName  string // 应用名称，数据保存在 <Dir>/<AppName>/<FileName>
+	Dir      AppDir // 数据目录的位置，默认 AppDirRoaming
+	FileName string // 数据文件名，默认 storage.dat
+	Path     string // 数据文件的完整路径，设置后忽略 AppName、Dir 和 FileName
+
+	FilePerm os.FileMode // 数据文件权限，默认 0644
+	DirPerm  os.FileMode // 自动创建的目录权限，默认 0755
+	Sync     SyncPolicy  // 刷盘策略，默认 SyncNone
+
+	Backups         int           // 保留的备份代数，0 使用 DefaultStorageBackups，负数表示不保留
+	Cache           CacheOptions  // 缓存模式，零值表示不启用
+	JanitorInterval time.Duration // 后台清理过期数据的间隔，0 表示不启动
+}
+
+// OpenStorage 按配置打开（或创建）存储
+func OpenStorage(opts StorageOptions) (*Storage, error) {
+	filePath, err := opts.filePath()
+	if err != nil {
+		return nil, err
+	}
+
+	dirPerm := opts.DirPerm
+	if dirPerm == 0 {
+		dirPerm = 0755
+	}
+	if err := os.MkdirAll(filepath.Dir(filePath), dirPerm); err != nil {
+		return nil, fmt.Errorf("无法创建目录: %w", err)
+	}
+
+	s := &Storage{
+		data:     make(map[string]*StorageItem),
+		filePath: filePath,
+		dirty:    false,
+		backups:  opts.Backups,
+		perm:     opts.FilePerm,
+		dirPerm:  dirPerm,
+		sync:     opts.Sync,
+	}
+	if s.backups == 0 {
+		s.backups = DefaultStorageBackups
+	} else if s.backups < 0 {
+		s.backups = 0
+	}
+	if s.perm == 0 {
+		s.perm = 0644
+	}
+
+	// 尝试加载已有数据
+	err = s.Load()
+	if errors.Is(err, ErrStorageCorrupted) {
+		// 主文件和备份都无法恢复时不阻止应用启动，损坏的文件移到一旁，可通过 Repair 的报告查看
+		err = s.quarantine()
+	}
+	if err != nil {
+		// 文件不存在是正常现象，忽略错误
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("加载数据失败: %w", err)
+		}
+		s.schema = CurrentSchemaVersion()
+	}
+
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.SetCacheOptions(opts.Cache); err != nil {
+		return nil, err
+	}
+	s.StartJanitor(opts.JanitorInterval)
+
+	return s, nil
+}
+
+// filePath 返回数据文件的完整路径
+func (opts StorageOptions) filePath() (string, error) {
+	if opts.Path != "" {
+		return opts.Path, nil
+	}
+	if opts.AppName == "" {
+		return "", fmt.Errorf("StorageOptions 需要设置 AppName 或 Path")
+	}
+
+	dir, err := ResolveAppDir(opts.Dir, opts.AppName)
+	if err != nil {
+		return "", err
+	}
+
+	fileName := opts.FileName
+	if fileName == "" {
+		fileName = "storage.dat"
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// writeStorageFile 写入文件，按刷盘策略在关闭前刷盘
+func writeStorageFile(path string, raw []byte, perm os.FileMode, sync SyncPolicy) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(raw); err != nil {
+		f.Close()
+		return err
+	}
+	if sync == SyncAlways {
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	return f.Close()
+}
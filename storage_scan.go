@@ -0,0 +1,145 @@
+package gohl
+
+import (
+	"sort"
+	"strings"
+)
+
+// scanPageSize ScanIterator 每次从 Storage 读取的条目数
+const scanPageSize = 256
+
+// KeyValue 有序扫描返回的键值对
+type KeyValue struct {
+	Key   string
+	Value interface{}
+}
+
+// ScanIterator 按键顺序遍历的迭代器，分页读取，遍历期间可以修改 Storage
+type ScanIterator struct {
+	s       *Storage
+	prefix  string
+	last    string
+	page    []KeyValue
+	pos     int
+	done    bool
+	current KeyValue
+}
+
+// Scan 按键的字典序返回以 prefix 开头、且大于 startAfter 的未过期键值对
+// limit <= 0 表示不限制数量；将上一页最后一个键作为 startAfter 即可实现分页
+// prefix 为空时不包括 Bucket 中的键
+func (s *Storage) Scan(prefix, startAfter string, limit int) []KeyValue {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var result []KeyValue
+	for i := s.scanStartLocked(prefix, startAfter); i < len(s.sortedKeys); i++ {
+		key := s.sortedKeys[i]
+		if !strings.HasPrefix(key, prefix) {
+			break
+		}
+		if prefix == "" && isBucketKey(key) {
+			continue
+		}
+		item := s.data[key]
+		if item == nil || item.IsExpired() {
+			continue
+		}
+		result = append(result, KeyValue{Key: key, Value: item.Value})
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+	}
+
+	return result
+}
+
+// ScanIter 返回以 prefix 开头、且大于 startAfter 的有序迭代器
+func (s *Storage) ScanIter(prefix, startAfter string) *ScanIterator {
+	return &ScanIterator{s: s, prefix: prefix, last: startAfter}
+}
+
+// Next 移动到下一个键值对，没有更多数据时返回 false
+func (it *ScanIterator) Next() bool {
+	if it.pos >= len(it.page) {
+		if it.done {
+			return false
+		}
+		it.page = it.s.Scan(it.prefix, it.last, scanPageSize)
+		it.pos = 0
+		if len(it.page) < scanPageSize {
+			it.done = true
+		}
+		if len(it.page) == 0 {
+			return false
+		}
+		it.last = it.page[len(it.page)-1].Key
+	}
+
+	it.current = it.page[it.pos]
+	it.pos++
+	return true
+}
+
+// Key 当前键
+func (it *ScanIterator) Key() string {
+	return it.current.Key
+}
+
+// Value 当前值
+func (it *ScanIterator) Value() interface{} {
+	return it.current.Value
+}
+
+// scanStartLocked 返回扫描的起始下标（已加锁）
+func (s *Storage) scanStartLocked(prefix, startAfter string) int {
+	if startAfter < prefix {
+		return sort.SearchStrings(s.sortedKeys, prefix)
+	}
+
+	i := sort.SearchStrings(s.sortedKeys, startAfter)
+	if i < len(s.sortedKeys) && s.sortedKeys[i] == startAfter {
+		i++
+	}
+	return i
+}
+
+// insertSortedKeyLocked 将新键插入有序索引（已加锁）
+func (s *Storage) insertSortedKeyLocked(key string) {
+	i := sort.SearchStrings(s.sortedKeys, key)
+	if i < len(s.sortedKeys) && s.sortedKeys[i] == key {
+		return
+	}
+	s.sortedKeys = append(s.sortedKeys, "")
+	copy(s.sortedKeys[i+1:], s.sortedKeys[i:])
+	s.sortedKeys[i] = key
+}
+
+// removeSortedKeyLocked 从有序索引中移除键（已加锁）
+func (s *Storage) removeSortedKeyLocked(key string) {
+	i := sort.SearchStrings(s.sortedKeys, key)
+	if i < len(s.sortedKeys) && s.sortedKeys[i] == key {
+		s.sortedKeys = append(s.sortedKeys[:i], s.sortedKeys[i+1:]...)
+	}
+}
+
+// pruneSortedKeysLocked 批量删除后移除有序索引中已不存在的键（已加锁）
+func (s *Storage) pruneSortedKeysLocked() {
+	keys := s.sortedKeys[:0]
+	for _, key := range s.sortedKeys {
+		if _, exists := s.data[key]; exists {
+			keys = append(keys, key)
+		}
+	}
+	s.sortedKeys = keys
+}
+
+// rebuildSortedKeysLocked 在整体替换数据后重建有序索引（已加锁）
+func (s *Storage) rebuildSortedKeysLocked() {
+	keys := make([]string, 0, len(s.data))
+	for key := range s.data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	s.sortedKeys = keys
+}
@@ -0,0 +1,171 @@
+package gohl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotExt 快照文件扩展名，快照保存在 <file>.snapshots 目录中
+const snapshotExt = ".snap"
+
+// SnapshotInfo 快照信息
+type SnapshotInfo struct {
+	Name      string
+	CreatedAt time.Time
+	Size      int64
+}
+
+// Snapshot 将当前数据（包括其他进程已写入的数据）保存为名为 name 的快照，同名快照会被覆盖
+// 快照期间其他写入会被阻塞，因此快照内容是一致的
+func (s *Storage) Snapshot(name string) error {
+	path, err := s.snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.withFileLockLocked(func() error {
+		if err := s.writeLocked(); err != nil {
+			return err
+		}
+
+		data := make(map[string]*StorageItem, len(s.data))
+		for key, item := range s.data {
+			if !item.IsExpired() {
+				data[key] = item
+			}
+		}
+
+		raw, err := encodeStorageFile(&storageFile{
+			Data:    data,
+			Indexes: s.persistIndexesLocked(),
+			schema:  s.schema,
+			token:   newStorageToken(),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), s.dirPerm); err != nil {
+			return fmt.Errorf("无法创建快照目录: %w", err)
+		}
+		tempFile := path + ".tmp"
+		if err := writeStorageFile(tempFile, raw, s.perm, s.sync); err != nil {
+			return fmt.Errorf("写入快照失败: %w", err)
+		}
+		if err := os.Rename(tempFile, path); err != nil {
+			os.Remove(tempFile)
+			return fmt.Errorf("写入快照失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListSnapshots 获取所有快照，按创建时间排序（最早的在前）
+func (s *Storage) ListSnapshots() ([]SnapshotInfo, error) {
+	entries, err := os.ReadDir(s.snapshotDir())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var snapshots []SnapshotInfo
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), snapshotExt) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, SnapshotInfo{
+			Name:      strings.TrimSuffix(entry.Name(), snapshotExt),
+			CreatedAt: info.ModTime(),
+			Size:      info.Size(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.Before(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Restore 用快照替换全部数据并保存，未保存的本地修改会被丢弃
+// 快照的 schema 版本低于当前版本时先执行迁移，迁移失败时不做任何修改
+func (s *Storage) Restore(name string) error {
+	path, err := s.snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	file, err := readStorageFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("snapshot not found: %s", name)
+		}
+		return fmt.Errorf("读取快照失败: %w", err)
+	}
+
+	data := make(map[string]*StorageItem, len(file.Data))
+	for key, item := range file.Data {
+		if !item.IsExpired() {
+			data[key] = item
+		}
+	}
+
+	// 先在副本上完成迁移，失败时数据和文件都保持不变
+	migrated, schema, err := migrateData(data, file.schema)
+	if err != nil {
+		return err
+	}
+	indexes := file.Indexes
+	if schema != file.schema {
+		// 迁移后文件中保存的索引已不可信，重新构建
+		indexes = nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.withFileLockLocked(func() error {
+		s.replaceAllLocked(migrated)
+		s.rebuildIndexesLocked(indexes)
+		s.schema = schema
+		return s.writeLocked()
+	})
+}
+
+// DeleteSnapshot 删除快照
+func (s *Storage) DeleteSnapshot(name string) error {
+	path, err := s.snapshotPath(name)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除快照失败: %w", err)
+	}
+	return nil
+}
+
+// snapshotDir 快照目录
+func (s *Storage) snapshotDir() string {
+	return s.FilePath() + ".snapshots"
+}
+
+// snapshotPath 返回快照文件路径，name 不能包含路径分隔符
+func (s *Storage) snapshotPath(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\:`) {
+		return "", fmt.Errorf("无效的快照名称: %q", name)
+	}
+	return filepath.Join(s.snapshotDir(), name+snapshotExt), nil
+}
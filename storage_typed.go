@@ -0,0 +1,124 @@
+package gohl
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// typedValue 泛型写入的值，以 gob 编码的字节保存，调用方无需注册具体类型
+type typedValue struct {
+	Type string
+	Data []byte
+}
+
+func init() {
+	gob.Register(typedValue{})
+}
+
+// ValueStore 泛型读写函数使用的键值存储，*Storage 和 *Bucket 均实现该接口
+type ValueStore interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}) error
+	SetWithTTL(key string, value interface{}, ttl time.Duration) error
+}
+
+var (
+	_ ValueStore = (*Storage)(nil)
+	_ ValueStore = (*Bucket)(nil)
+)
+
+// typeNameOf 返回类型 T 的名称，用于读取时校验类型
+func typeNameOf[T any]() string {
+	return reflect.TypeOf((*T)(nil)).Elem().String()
+}
+
+// isBuiltinType 判断是否为 gob 内置支持的基础类型，这类值直接保存，
+// 以便 GetString/GetInt/GetBool 等方法仍能读取
+func isBuiltinType(t reflect.Type) bool {
+	if t == nil || t.PkgPath() != "" {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// encodeTyped 将值编码为可持久化的形式
+func encodeTyped[T any](value T) (interface{}, error) {
+	if isBuiltinType(reflect.TypeOf((*T)(nil)).Elem()) {
+		return value, nil
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(&value); err != nil {
+		return nil, fmt.Errorf("编码 %s 失败: %w", typeNameOf[T](), err)
+	}
+
+	return typedValue{Type: typeNameOf[T](), Data: buf.Bytes()}, nil
+}
+
+// SetTyped 以类型 T 保存值（无TTL），支持任意结构体、切片和 map
+func SetTyped[T any](s ValueStore, key string, value T) error {
+	v, err := encodeTyped(value)
+	if err != nil {
+		return err
+	}
+	return s.Set(key, v)
+}
+
+// SetTypedWithTTL 以类型 T 保存带TTL的值
+func SetTypedWithTTL[T any](s ValueStore, key string, value T, ttl time.Duration) error {
+	v, err := encodeTyped(value)
+	if err != nil {
+		return err
+	}
+	return s.SetWithTTL(key, v, ttl)
+}
+
+// GetAs 以类型 T 读取值
+// 键不存在（或已过期）时返回 false；键存在但类型不符时返回错误
+func GetAs[T any](s ValueStore, key string) (T, bool, error) {
+	value, exists := s.Get(key)
+	if !exists {
+		var zero T
+		return zero, false, nil
+	}
+
+	v, err := ValueAs[T](value)
+	if err != nil {
+		return v, true, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, true, nil
+}
+
+// ValueAs 将 Scan、Lookup 等返回的值转换为类型 T，类型不符时返回错误
+func ValueAs[T any](value interface{}) (T, error) {
+	var zero T
+	want := typeNameOf[T]()
+
+	if tv, ok := value.(typedValue); ok {
+		if tv.Type != want {
+			return zero, fmt.Errorf("类型不匹配: 值的类型为 %s，而不是 %s", tv.Type, want)
+		}
+
+		var out T
+		if err := gob.NewDecoder(bytes.NewReader(tv.Data)).Decode(&out); err != nil {
+			return zero, fmt.Errorf("解码 %s 失败: %w", want, err)
+		}
+		return out, nil
+	}
+
+	if v, ok := value.(T); ok {
+		return v, nil
+	}
+
+	return zero, fmt.Errorf("类型不匹配: 值的类型为 %T，而不是 %s", value, want)
+}